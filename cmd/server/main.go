@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"go-svc-metrics/internal/alerting"
 	"go-svc-metrics/internal/config"
 	"go-svc-metrics/internal/domain"
 	"go-svc-metrics/internal/logger"
@@ -40,6 +41,11 @@ func main() {
 
 	serviceApp := service.NewMetricService(repo)
 
+	alertRules, err := alerting.NewRules(configServe.AlertRules)
+	if err != nil {
+		logger.Log.Fatal("cannot load alert rules", zap.Error(err))
+	}
+
 	serverHTTP, err := http_server.NewApp(configServe, serviceApp)
 	if err != nil {
 		logger.Log.Fatal("cannot initialize server", zap.Error(err))
@@ -56,6 +62,20 @@ func main() {
 	serverCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer stop()
 
+	// Сохранение перезапускается после паники и останавливается после серверов,
+	// чтобы последний снимок включал все принятые обновления.
+	flushCtx, stopFlush := context.WithCancel(context.Background())
+	defer stopFlush()
+	flushDone := make(chan struct{})
+	go func() {
+		defer close(flushDone)
+		if err := serviceApp.DumpMetricsByInterval(flushCtx); err != nil {
+			logger.Log.Error("cannot dump metrics on shutdown", zap.Error(err))
+		}
+	}()
+
+	go serviceApp.ExpireHistoryByInterval(serverCtx, configServe.HistorySweep.Duration)
+
 	go func() {
 		if err := serverHTTP.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Log.Fatal(err.Error())
@@ -70,18 +90,43 @@ func main() {
 		}()
 	}
 
+	if len(alertRules) > 0 {
+		alertEngine := alerting.NewEngine(serviceApp, alertRules, configServe.AlertInterval.Duration)
+		go alertEngine.Run(serverCtx)
+
+		if len(configServe.AlertWebhooks) > 0 {
+			notifier := alerting.NewWebhookNotifier(alertEngine, alerting.WebhookConfig{
+				URLs:           configServe.AlertWebhooks,
+				Key:            *configServe.Key,
+				GroupInterval:  configServe.AlertGroupInterval.Duration,
+				RepeatInterval: configServe.AlertRepeatInterval.Duration,
+			})
+			go notifier.Run(serverCtx)
+		}
+	}
+
 	<-serverCtx.Done()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), configServe.Wait.Duration)
 	defer cancel()
 
 	if err := serverHTTP.Stop(shutdownCtx); err != nil {
-		logger.Log.Fatal(err.Error())
+		logger.Log.Error("cannot stop http server", zap.Error(err))
 	}
 
 	if serverGRPC != nil {
-		serverGRPC.Stop()
+		serverGRPC.Stop(shutdownCtx)
 	}
 
-	serviceApp.DumpMetricsByInterval(shutdownCtx)
+	stopFlush()
+	select {
+	case <-flushDone:
+	case <-shutdownCtx.Done():
+		logger.Log.Error("metrics dump timed out on shutdown")
+	}
+
+	// Close синхронизирует журнал и закрывает хранилище.
+	if err := serviceApp.Close(); err != nil {
+		logger.Log.Error("cannot close storage", zap.Error(err))
+	}
 }
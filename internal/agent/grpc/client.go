@@ -2,14 +2,18 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"go-svc-metrics/internal/config"
 	pb "go-svc-metrics/internal/pb/metric"
+	"go-svc-metrics/internal/utils/crypto"
 	"go-svc-metrics/models"
 
 	"go-svc-metrics/internal/interceptors"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 type ClientAgent struct {
@@ -20,17 +24,25 @@ type ClientAgent struct {
 func NewClientAgent(agentConfig *config.Config) (*ClientAgent, error) {
 	opts := []grpc.DialOption{}
 
-	if agentConfig.CryptoKey != nil {
-		tlsCreds, err := generateTLSCreds(*agentConfig.CryptoKey)
+	if isTLSEnabled(agentConfig) {
+		tlsCreds, err := generateTLSCreds(agentConfig)
 		if err != nil {
 			return nil, err
 		}
 
 		opts = append(opts, grpc.WithTransportCredentials(tlsCreds))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
 	if agentConfig.RealIP != nil {
 		opts = append(opts, grpc.WithChainUnaryInterceptor(interceptors.NewRealIPClientInterceptor(*agentConfig.RealIP)))
+		opts = append(opts, grpc.WithChainStreamInterceptor(interceptors.NewRealIPStreamClientInterceptor(*agentConfig.RealIP)))
+	}
+
+	if agentConfig.Key != nil && *agentConfig.Key != "" {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(interceptors.NewHashClientInterceptor(*agentConfig.Key)))
+		opts = append(opts, grpc.WithChainStreamInterceptor(interceptors.NewHashStreamClientInterceptor(*agentConfig.Key)))
 	}
 
 	conn, err := grpc.NewClient(*agentConfig.AddrGRPC, opts...)
@@ -59,6 +71,35 @@ func (c *ClientAgent) SendBatchMetrics(ctx context.Context, metrics []models.Met
 	return nil
 }
 
-func generateTLSCreds(certFile string) (credentials.TransportCredentials, error) {
-	return credentials.NewClientTLSFromFile(certFile, "")
+func isTLSEnabled(agentConfig *config.Config) bool {
+	return (agentConfig.CACert != nil && *agentConfig.CACert != "") ||
+		(agentConfig.CryptoKey != nil && *agentConfig.CryptoKey != "")
+}
+
+// generateTLSCreds проверяет сервер по CA (или по его сертификату из crypto-key)
+// и, если задан клиентский сертификат, предъявляет его серверу для mTLS.
+func generateTLSCreds(agentConfig *config.Config) (credentials.TransportCredentials, error) {
+	rootCAFile := *agentConfig.CryptoKey
+	if agentConfig.CACert != nil && *agentConfig.CACert != "" {
+		rootCAFile = *agentConfig.CACert
+	}
+
+	rootCAs, err := crypto.GetCertPool(rootCAFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{RootCAs: rootCAs}
+
+	if agentConfig.ClientCert != nil && *agentConfig.ClientCert != "" {
+		if agentConfig.ClientKey == nil || *agentConfig.ClientKey == "" {
+			return nil, fmt.Errorf("client-cert requires client-key")
+		}
+
+		clientCert, err := tls.LoadX509KeyPair(*agentConfig.ClientCert, *agentConfig.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	return credentials.NewTLS(tlsConfig), nil
 }
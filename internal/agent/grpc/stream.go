@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"go-svc-metrics/internal/config"
+	pb "go-svc-metrics/internal/pb/metric"
+	"go-svc-metrics/models"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamClientAgent отправляет батчи метрик в одном долгоживущем потоке.
+// При сбое поток закрывается и открывается заново при следующей отправке.
+type StreamClientAgent struct {
+	*ClientAgent
+	mutex  sync.Mutex
+	stream pb.Metrcic_V1StreamMetricsClient
+	cancel context.CancelFunc
+}
+
+func NewStreamClientAgent(agentConfig *config.Config) (*StreamClientAgent, error) {
+	clientAgent, err := NewClientAgent(agentConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamClientAgent{ClientAgent: clientAgent}, nil
+}
+
+// SendBatchMetrics отправляет батч метрик в поток и ждет подтверждения.
+func (c *StreamClientAgent) SendBatchMetrics(ctx context.Context, metrics []models.Metrics) error {
+	// Подтверждения приходят по порядку, поэтому в потоке не больше одного батча.
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stream, err := c.getStream()
+	if err != nil {
+		return err
+	}
+
+	var batchMetrics models.BatchMetrics = metrics
+	if err := stream.Send(&pb.StreamMetricsRequest{Batch: batchMetrics.ToProto()}); err != nil {
+		if errors.Is(err, io.EOF) {
+			// Причину закрытия потока возвращает Recv.
+			_, err = stream.Recv()
+		}
+		c.resetStream()
+		return err
+	}
+
+	ack, err := c.recvAck(ctx, stream)
+	if err != nil {
+		c.resetStream()
+		return err
+	}
+
+	if codes.Code(ack.GetCode()) != codes.OK {
+		return status.Error(codes.Code(ack.GetCode()), ack.GetMessage())
+	}
+	return nil
+}
+
+func (c *StreamClientAgent) recvAck(ctx context.Context, stream pb.Metrcic_V1StreamMetricsClient) (*pb.StreamAck, error) {
+	type result struct {
+		ack *pb.StreamAck
+		err error
+	}
+
+	resultCh := make(chan result, 1)
+	go func() {
+		ack, err := stream.Recv()
+		resultCh <- result{ack: ack, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		// Подтверждение может прийти позже и перепутаться со следующим батчем.
+		return nil, ctx.Err()
+	case res := <-resultCh:
+		if errors.Is(res.err, io.EOF) {
+			return nil, status.Error(codes.Unavailable, "metrics stream closed by server")
+		}
+		return res.ack, res.err
+	}
+}
+
+func (c *StreamClientAgent) getStream() (pb.Metrcic_V1StreamMetricsClient, error) {
+	if c.stream != nil {
+		return c.stream, nil
+	}
+
+	streamCtx, cancel := context.WithCancel(context.Background())
+	stream, err := c.metricClient.V1StreamMetrics(streamCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	c.stream = stream
+	c.cancel = cancel
+	return stream, nil
+}
+
+func (c *StreamClientAgent) resetStream() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.stream = nil
+	c.cancel = nil
+}
+
+func (c *StreamClientAgent) ConnClose() {
+	c.mutex.Lock()
+	if c.stream != nil {
+		c.stream.CloseSend()
+	}
+	c.resetStream()
+	c.mutex.Unlock()
+
+	c.ClientAgent.ConnClose()
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"go-svc-metrics/internal/config"
+	"go-svc-metrics/internal/interceptors"
+	pb "go-svc-metrics/internal/pb/metric"
+	"go-svc-metrics/models"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeStreamServer struct {
+	pb.UnimplementedMetrcicServer
+	streams atomic.Int32
+}
+
+// V1StreamMetrics отклоняет пустые батчи и закрывает поток после батча с метрикой close.
+func (f *fakeStreamServer) V1StreamMetrics(stream pb.Metrcic_V1StreamMetricsServer) error {
+	f.streams.Add(1)
+	for {
+		in, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		metrics := in.GetBatch().GetMetrics()
+		if len(metrics) == 0 {
+			if err := stream.Send(&pb.StreamAck{Code: int32(codes.InvalidArgument), Message: "empty batch"}); err != nil {
+				return err
+			}
+			continue
+		}
+		if metrics[0].GetId() == "close" {
+			return status.Error(codes.Unavailable, "server is restarting")
+		}
+		if err := stream.Send(&pb.StreamAck{}); err != nil {
+			return err
+		}
+	}
+}
+
+func TestStreamClientAgent(t *testing.T) {
+	key := "secret"
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	fakeServer := &fakeStreamServer{}
+	server := grpc.NewServer(grpc.ChainStreamInterceptor(interceptors.NewHashStreamInterceptor(key)))
+	pb.RegisterMetrcicServer(server, fakeServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	addr := listener.Addr().String()
+	realIP := "127.0.0.1"
+	client, err := NewStreamClientAgent(&config.Config{AddrGRPC: &addr, RealIP: &realIP, Key: &key})
+	require.NoError(t, err)
+	defer client.ConnClose()
+
+	value := 1.0
+	batch := func(id string) []models.Metrics {
+		return []models.Metrics{{ID: id, MType: models.Gauge, Value: &value}}
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, client.SendBatchMetrics(ctx, batch("Alloc")))
+	assert.NoError(t, client.SendBatchMetrics(ctx, batch("Frees")))
+	assert.Equal(t, codes.InvalidArgument, status.Code(client.SendBatchMetrics(ctx, nil)))
+	assert.Equal(t, int32(1), fakeServer.streams.Load())
+
+	assert.Equal(t, codes.Unavailable, status.Code(client.SendBatchMetrics(ctx, batch("close"))))
+	assert.NoError(t, client.SendBatchMetrics(ctx, batch("Alloc")))
+	assert.Equal(t, int32(2), fakeServer.streams.Load())
+}
@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"crypto/sha256"
-	"crypto/x509"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"go-svc-metrics/internal/config"
@@ -13,14 +14,26 @@ import (
 	"go-svc-metrics/internal/utils/delay"
 	"go-svc-metrics/models"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 const (
-	updatePath              = "http://%s/update/"
-	updatesBatchMetricsPath = "http://%s/updates/"
+	updatePath              = "%s://%s/update/"
+	updatesBatchMetricsPath = "%s://%s/updates/"
+	hashHeader              = "HashSHA256"
+	encryptionVersionHeader = "X-Encryption-Version"
 )
 
+// StatusError сервер ответил кодом, отличным от 2xx.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
 type retryRoundTripper struct {
 	next       http.RoundTripper
 	maxRetries uint
@@ -29,7 +42,7 @@ type retryRoundTripper struct {
 func (rr retryRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 	var res *http.Response
 	var err error
-	delay := delay.NewDelay()
+	delay := delay.NewDelay(time.Second, func(delay time.Duration) time.Duration { return delay + 2*time.Second })
 	for attempts := 0; attempts < int(rr.maxRetries); attempts++ {
 		res, err = rr.next.RoundTrip(r)
 		if err == nil && res.StatusCode < http.StatusInternalServerError {
@@ -50,11 +63,25 @@ func (rr retryRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 type ClientAgent struct {
 	httpClient *http.Client
 	config     *config.Config
-	cert       *x509.Certificate
+	publicKey  *rsa.PublicKey
 }
 
 func NewClientAgent(agentConfig *config.Config) (*ClientAgent, error) {
-	cert, err := crypto.GetCertificate(*agentConfig.CryptoKey)
+	var publicKey *rsa.PublicKey
+	if agentConfig.CryptoKey != nil && *agentConfig.CryptoKey != "" {
+		cert, err := crypto.GetCertificate(*agentConfig.CryptoKey)
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("certificate %s has no RSA public key", *agentConfig.CryptoKey)
+		}
+		publicKey = key
+	}
+
+	transport, err := newTransport(agentConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -64,13 +91,57 @@ func NewClientAgent(agentConfig *config.Config) (*ClientAgent, error) {
 		httpClient: &http.Client{
 			Transport: &retryRoundTripper{
 				maxRetries: 3,
-				next:       http.DefaultTransport,
+				next:       transport,
 			},
 		},
-		cert: cert,
+		publicKey: publicKey,
 	}, nil
 }
 
+// newTransport настраивает TLS для https: сервер проверяется по CA из ca-cert,
+// клиентский сертификат предъявляется, если он задан.
+func newTransport(agentConfig *config.Config) (http.RoundTripper, error) {
+	if !isHTTPS(agentConfig) {
+		return http.DefaultTransport, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if agentConfig.CACert != nil && *agentConfig.CACert != "" {
+		rootCAs, err := crypto.GetCertPool(*agentConfig.CACert)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if agentConfig.ClientCert != nil && *agentConfig.ClientCert != "" {
+		if agentConfig.ClientKey == nil || *agentConfig.ClientKey == "" {
+			return nil, fmt.Errorf("client-cert requires client-key")
+		}
+
+		clientCert, err := tls.LoadX509KeyPair(*agentConfig.ClientCert, *agentConfig.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+func isHTTPS(agentConfig *config.Config) bool {
+	return agentConfig.HTTPS != nil && *agentConfig.HTTPS
+}
+
+func (c *ClientAgent) scheme() string {
+	if isHTTPS(c.config) {
+		return "https"
+	}
+	return "http"
+}
+
 func (c *ClientAgent) ConnClose() {
 
 }
@@ -88,7 +159,7 @@ func (c *ClientAgent) SendOneMetric(ctx context.Context, metric models.Metrics)
 	}
 
 	defer response.Body.Close()
-	return nil
+	return checkStatus(response)
 }
 
 // SendBatchMetrics отправляет батч метрик на сервер.
@@ -104,7 +175,7 @@ func (c *ClientAgent) SendBatchMetrics(ctx context.Context, metrics []models.Met
 	}
 
 	defer response.Body.Close()
-	return nil
+	return checkStatus(response)
 }
 
 func (c *ClientAgent) sendMetric(ctx context.Context, metricData []byte, updatePath string) (*http.Response, error) {
@@ -125,12 +196,19 @@ func (c *ClientAgent) sendMetric(ctx context.Context, metricData []byte, updateP
 	return c.httpClient.Do(request)
 }
 
+func checkStatus(response *http.Response) error {
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return &StatusError{StatusCode: response.StatusCode}
+	}
+	return nil
+}
+
 func (c *ClientAgent) getUpdatePath() string {
-	return fmt.Sprintf(updatePath, c.config.GetServeAddress())
+	return fmt.Sprintf(updatePath, c.scheme(), c.config.GetServeAddress())
 }
 
 func (c *ClientAgent) getUpdateBatchPath() string {
-	return fmt.Sprintf(updatesBatchMetricsPath, c.config.GetServeAddress())
+	return fmt.Sprintf(updatesBatchMetricsPath, c.scheme(), c.config.GetServeAddress())
 }
 
 // Compress сжимает данные перед отправкой на сервер.
@@ -149,15 +227,25 @@ func Compress(data []byte) ([]byte, error) {
 }
 
 func (c *ClientAgent) getRequest(ctx context.Context, url string, data []byte) (*http.Request, error) {
-	if c.cert != nil {
-		hash := sha256.New()
-
-		ciphertext, err := crypto.EncryptRSAData(hash, c.cert, data)
+	if c.publicKey != nil {
+		ciphertext, err := crypto.EncryptHybrid(c.publicKey, data)
 		if err != nil {
 			return nil, err
 		}
+		data = ciphertext
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.publicKey != nil {
+		request.Header.Set(encryptionVersionHeader, strconv.Itoa(int(crypto.HybridVersion)))
+	}
 
-		return http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(ciphertext))
+	if c.config.Key != nil && *c.config.Key != "" {
+		request.Header.Set(hashHeader, hex.EncodeToString(crypto.GetHash(*c.config.Key, data)))
 	}
-	return http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(data))
+	return request, nil
 }
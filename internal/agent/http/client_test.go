@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"encoding/pem"
+	"go-svc-metrics/internal/config"
+	"go-svc-metrics/models"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendBatchMetricsHTTPS(t *testing.T) {
+	statusCode := http.StatusOK
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/updates/", r.URL.Path)
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caPath, caPEM, 0600))
+
+	serverAddr := strings.TrimPrefix(server.URL, "https://")
+	realIP := "127.0.0.1"
+	https := true
+	client, err := NewClientAgent(&config.Config{
+		ServerAddr: &serverAddr,
+		RealIP:     &realIP,
+		HTTPS:      &https,
+		CACert:     &caPath,
+	})
+	require.NoError(t, err)
+
+	value := 1.0
+	metrics := []models.Metrics{{ID: "Alloc", MType: models.Gauge, Value: &value}}
+	assert.NoError(t, client.SendBatchMetrics(context.Background(), metrics))
+
+	statusCode = http.StatusBadRequest
+	assert.Equal(t, &StatusError{StatusCode: http.StatusBadRequest}, client.SendBatchMetrics(context.Background(), metrics))
+}
+
+func TestNewClientAgentRequiresClientKey(t *testing.T) {
+	serverAddr := "localhost:8080"
+	realIP := "127.0.0.1"
+	https := true
+	clientCert := "client.pem"
+	clientKey := ""
+	_, err := NewClientAgent(&config.Config{
+		ServerAddr: &serverAddr,
+		RealIP:     &realIP,
+		HTTPS:      &https,
+		ClientCert: &clientCert,
+		ClientKey:  &clientKey,
+	})
+	assert.EqualError(t, err, "client-cert requires client-key")
+}
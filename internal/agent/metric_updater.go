@@ -5,22 +5,28 @@ package agent
 import (
 	"context"
 	"fmt"
-	grpc_client "go-svc-metrics/internal/agent/grpc"
 	"go-svc-metrics/internal/config"
 	"go-svc-metrics/internal/logger"
+	"go-svc-metrics/internal/sketch"
 	"go-svc-metrics/models"
 	"math/rand"
 	"os/signal"
 	"runtime"
+	"strconv"
 	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/mem"
+	"go.uber.org/zap"
 )
 
-const counterMetricName = "PollCount"
+const (
+	counterMetricName = "PollCount"
+	gcPauseMetricName = "GCPause"
+	cpuLabel          = "cpu"
+)
 
 type MetricSender interface {
 	SendBatchMetrics(ctx context.Context, metrics []models.Metrics) error
@@ -30,8 +36,11 @@ type MetricSender interface {
 // MetricUpdater хранит метрики и конфиг.
 type MetricUpdater struct {
 	clientAgent MetricSender
+	spool       *Spool
 	*config.Config
 	CounterMetric *int64
+	// lastNumGC количество сборок мусора на момент прошлого опроса.
+	lastNumGC uint32
 }
 
 // NewMetricUpdater создает новый MetricUpdater
@@ -41,13 +50,23 @@ func NewMetricUpdater() (*MetricUpdater, error) {
 		return nil, err
 	}
 
-	clientAgent, err := grpc_client.NewClientAgent(agentConfig)
+	clientAgent, err := NewMetricSender(agentConfig)
 	if err != nil {
 		return nil, err
 	}
 
+	var spool *Spool
+	if agentConfig.SpoolDir != nil && *agentConfig.SpoolDir != "" {
+		spool, err = NewSpool(*agentConfig.SpoolDir, *agentConfig.SpoolMaxSize, *agentConfig.SpoolDropPolicy)
+		if err != nil {
+			clientAgent.ConnClose()
+			return nil, err
+		}
+	}
+
 	return &MetricUpdater{
 		clientAgent:   clientAgent,
+		spool:         spool,
 		Config:        agentConfig,
 		CounterMetric: new(int64),
 	}, nil
@@ -95,7 +114,21 @@ func (m *MetricUpdater) metricGenerator(ctx context.Context, errorCh chan<- erro
 				if err != nil {
 					errorCh <- err
 				}
-				metricCh <- metrics
+				if m.spool == nil {
+					// Без очереди на диске опрос ждет отправки, чтобы не терять батчи.
+					select {
+					case metricCh <- metrics:
+					case <-ctx.Done():
+						return
+					}
+					continue
+				}
+				select {
+				case metricCh <- metrics:
+				default:
+					// Отправка не успевает, батч откладывается в очередь на диске.
+					m.spoolMetrics(metrics)
+				}
 			}
 		}
 	}()
@@ -123,12 +156,43 @@ func (m *MetricUpdater) sendAllMetric(ctx context.Context, metricCh <-chan []mod
 
 // MetricSenderWorker полуает батч метрик и отправляет батчами на сервер метрики.
 func (m *MetricUpdater) MetricSenderWorker(ctx context.Context, metricCh <-chan []models.Metrics) {
-	metrics := <-metricCh
+	metrics, ok := <-metricCh
+	if !ok {
+		return
+	}
+
+	if m.spool != nil && m.spool.Len() > 0 {
+		// Батч встает в конец очереди, чтобы метрики дошли до сервера по порядку.
+		m.spoolMetrics(metrics)
+		m.replaySpool(ctx)
+		return
+	}
+
 	err := m.clientAgent.SendBatchMetrics(ctx, metrics)
 	if err != nil {
 		logger.Log.Warn(err.Error())
+		if isRetryable(err) {
+			m.spoolMetrics(metrics)
+		}
 	}
+}
 
+// spoolMetrics сохраняет батч в очередь на диске, если она включена.
+func (m *MetricUpdater) spoolMetrics(metrics []models.Metrics) {
+	if m.spool == nil {
+		logger.Log.Warn("metrics batch dropped", zap.Int("size", len(metrics)))
+		return
+	}
+	if err := m.spool.Append(metrics); err != nil {
+		logger.Log.Warn("failed to spool metrics batch", zap.Error(err))
+	}
+}
+
+// replaySpool отправляет батчи из очереди на диске.
+func (m *MetricUpdater) replaySpool(ctx context.Context) {
+	if err := m.spool.Replay(ctx, m.clientAgent.SendBatchMetrics); err != nil {
+		logger.Log.Warn("failed to replay spooled metrics", zap.Error(err))
+	}
 }
 
 // GetMetrics получение метрики с машины.
@@ -166,6 +230,9 @@ func (m *MetricUpdater) GetMetrics() ([]models.Metrics, error) {
 	metrics = append(metrics, m.getGaugeMetric("Sys", float64(memStats.Sys)))
 	metrics = append(metrics, m.getGaugeMetric("TotalAlloc", float64(memStats.TotalAlloc)))
 	metrics = append(metrics, m.getGaugeMetric("RandomValue", rand.Float64()))
+	if gcPause, ok := m.getGCPauseMetric(&memStats); ok {
+		metrics = append(metrics, gcPause)
+	}
 
 	v, err := mem.VirtualMemory()
 	if err != nil {
@@ -180,6 +247,10 @@ func (m *MetricUpdater) GetMetrics() ([]models.Metrics, error) {
 		return metrics, err
 	}
 	for i, percent := range c {
+		cpuMetric := m.getGaugeMetric("CPUutilization", percent)
+		cpuMetric.Labels = models.Labels{cpuLabel: strconv.Itoa(i + 1)}
+		metrics = append(metrics, cpuMetric)
+		// Прежние имена без меток, чтобы не сломать чтение /value/gauge/CPUutilization1.
 		metrics = append(metrics, m.getGaugeMetric(fmt.Sprintf("CPUutilization%d", i+1), percent))
 	}
 
@@ -195,6 +266,34 @@ func (m *MetricUpdater) getCounterMetric() models.Metrics {
 	}
 }
 
+// getGCPauseMetric возвращает summary пауз сборок мусора с прошлого опроса в секундах.
+// Сервер сливает скетчи агентов, поэтому квантили считаются по всем агентам.
+func (m *MetricUpdater) getGCPauseMetric(memStats *runtime.MemStats) (models.Metrics, bool) {
+	newGC := memStats.NumGC - m.lastNumGC
+	m.lastNumGC = memStats.NumGC
+	if newGC == 0 {
+		return models.Metrics{}, false
+	}
+	// PauseNs - кольцевой буфер последних пауз.
+	if newGC > uint32(len(memStats.PauseNs)) {
+		newGC = uint32(len(memStats.PauseNs))
+	}
+
+	pauses, err := sketch.New(sketch.DefaultRelativeAccuracy)
+	if err != nil {
+		return models.Metrics{}, false
+	}
+	for i := uint32(0); i < newGC; i++ {
+		index := (memStats.NumGC - 1 - i) % uint32(len(memStats.PauseNs))
+		pauses.Add(time.Duration(memStats.PauseNs[index]).Seconds())
+	}
+	return models.Metrics{
+		ID:     gcPauseMetricName,
+		MType:  models.Summary,
+		Sketch: pauses,
+	}, true
+}
+
 func (m *MetricUpdater) getGaugeMetric(name string, value float64) models.Metrics {
 	return models.Metrics{
 		ID:    name,
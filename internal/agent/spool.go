@@ -0,0 +1,274 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-svc-metrics/internal/logger"
+	"go-svc-metrics/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Политики при переполнении очереди.
+const (
+	// DropOldest удаляет самый старый сегмент, чтобы освободить место.
+	DropOldest = "oldest"
+	// DropNewest отбрасывает новый батч.
+	DropNewest = "newest"
+)
+
+const (
+	segmentExt = ".seg"
+	// segmentsPerSpool на сколько сегментов делится максимальный размер очереди.
+	segmentsPerSpool = 8
+)
+
+// ErrSpoolFull очередь заполнена, батч отброшен.
+var ErrSpoolFull = errors.New("spool is full")
+
+type segment struct {
+	seq  uint64
+	path string
+	size int64
+}
+
+// Spool ограниченная по размеру очередь батчей на диске.
+// Батчи дописываются в файлы-сегменты и отправляются в порядке записи.
+type Spool struct {
+	dir         string
+	maxSize     int64
+	segmentSize int64
+	dropPolicy  string
+
+	mutex    sync.Mutex
+	segments []*segment
+	size     int64
+	nextSeq  uint64
+	sealed   bool
+
+	replayMutex sync.Mutex
+}
+
+// NewSpool открывает очередь в каталоге dir и подхватывает ранее сохраненные сегменты.
+func NewSpool(dir string, maxSize int64, dropPolicy string) (*Spool, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid spool max size %d", maxSize)
+	}
+	if dropPolicy != DropOldest && dropPolicy != DropNewest {
+		return nil, fmt.Errorf("invalid spool drop policy %q", dropPolicy)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	spool := &Spool{
+		dir:         dir,
+		maxSize:     maxSize,
+		segmentSize: max(maxSize/segmentsPerSpool, 1),
+		dropPolicy:  dropPolicy,
+		sealed:      true,
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		spool.segments = append(spool.segments, &segment{seq: seq, path: filepath.Join(dir, name), size: info.Size()})
+		spool.size += info.Size()
+		spool.nextSeq = max(spool.nextSeq, seq+1)
+	}
+	sort.Slice(spool.segments, func(i, j int) bool { return spool.segments[i].seq < spool.segments[j].seq })
+	return spool, nil
+}
+
+// Len возвращает количество сегментов в очереди.
+func (s *Spool) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.segments)
+}
+
+// Append записывает батч в конец очереди.
+func (s *Spool) Append(metrics []models.Metrics) error {
+	line, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	lineSize := int64(len(line))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if lineSize > s.maxSize {
+		return ErrSpoolFull
+	}
+	for s.size+lineSize > s.maxSize {
+		if s.dropPolicy == DropNewest || len(s.segments) == 0 {
+			return ErrSpoolFull
+		}
+		if err := s.removeSegment(s.segments[0]); err != nil {
+			return err
+		}
+	}
+
+	last := s.lastSegment()
+	if last == nil || s.sealed || last.size+lineSize > s.segmentSize {
+		last = &segment{seq: s.nextSeq, path: filepath.Join(s.dir, fmt.Sprintf("%020d%s", s.nextSeq, segmentExt))}
+		s.nextSeq++
+		s.segments = append(s.segments, last)
+		s.sealed = false
+	}
+
+	file, err := os.OpenFile(last.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(line); err != nil {
+		return err
+	}
+	last.size += lineSize
+	s.size += lineSize
+	return file.Sync()
+}
+
+// Replay отправляет сохраненные батчи по порядку.
+// При первой ошибке, после которой стоит повторить отправку, она прекращается, неотправленные батчи остаются в очереди.
+// Батчи, отклоненные сервером, удаляются из очереди.
+// Если очередь уже отправляется другим воркером, Replay сразу возвращает nil.
+func (s *Spool) Replay(ctx context.Context, send func(ctx context.Context, metrics []models.Metrics) error) error {
+	if !s.replayMutex.TryLock() {
+		return nil
+	}
+	defer s.replayMutex.Unlock()
+
+	for {
+		s.mutex.Lock()
+		if len(s.segments) == 0 {
+			s.mutex.Unlock()
+			return nil
+		}
+		current := s.segments[0]
+		if current == s.lastSegment() {
+			// Новые батчи пишутся в следующий сегмент, пока этот отправляется.
+			s.sealed = true
+		}
+		s.mutex.Unlock()
+
+		if err := s.replaySegment(ctx, current, send); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Spool) replaySegment(ctx context.Context, current *segment, send func(ctx context.Context, metrics []models.Metrics) error) error {
+	data, err := os.ReadFile(current.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// Батч может быть больше сегмента, но не больше очереди. Сегмент прошлого запуска мог быть записан с большим лимитом.
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), int(max(s.maxSize, int64(len(data))))+1)
+	var offset int
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var metrics []models.Metrics
+		if err := json.Unmarshal(line, &metrics); err != nil {
+			logger.Log.Warn("skipping corrupted spool batch", zap.String("path", current.path), zap.Int("offset", offset), zap.Error(err))
+		} else if err := send(ctx, metrics); err != nil && isRetryable(err) {
+			return errors.Join(err, s.truncateSegment(current, data[offset:]))
+		} else if err != nil {
+			// Отклоненный сервером батч не пройдет и при повторе, а без удаления он бы навсегда остановил очередь.
+			logger.Log.Warn("dropping rejected spool batch", zap.String("path", current.path), zap.Int("size", len(metrics)), zap.Error(err))
+		}
+		offset += len(line) + 1
+	}
+	// Сегмент удаляется, только если прочитан целиком.
+	if err := scanner.Err(); err != nil {
+		return errors.Join(err, s.truncateSegment(current, data[offset:]))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.removeSegment(current)
+}
+
+// truncateSegment оставляет в сегменте только неотправленные батчи.
+func (s *Spool) truncateSegment(current *segment, rest []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.contains(current) {
+		return nil
+	}
+
+	tmpPath := current.path + ".tmp"
+	if err := os.WriteFile(tmpPath, rest, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, current.path); err != nil {
+		return err
+	}
+	s.size -= current.size - int64(len(rest))
+	current.size = int64(len(rest))
+	return nil
+}
+
+// removeSegment удаляет сегмент из очереди. Вызывается под mutex.
+func (s *Spool) removeSegment(current *segment) error {
+	for i, seg := range s.segments {
+		if seg != current {
+			continue
+		}
+		if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		s.segments = append(s.segments[:i], s.segments[i+1:]...)
+		s.size -= seg.size
+		return nil
+	}
+	return nil
+}
+
+func (s *Spool) contains(current *segment) bool {
+	for _, seg := range s.segments {
+		if seg == current {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Spool) lastSegment() *segment {
+	if len(s.segments) == 0 {
+		return nil
+	}
+	return s.segments[len(s.segments)-1]
+}
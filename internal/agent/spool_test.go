@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-svc-metrics/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testBatch(id string) []models.Metrics {
+	value := 1.0
+	return []models.Metrics{{ID: id, MType: models.Gauge, Value: &value}}
+}
+
+func TestSpoolReplayInOrder(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(dir, 1<<20, DropOldest)
+	require.NoError(t, err)
+
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, spool.Append(testBatch(id)))
+	}
+
+	// Очередь переживает перезапуск агента.
+	spool, err = NewSpool(dir, 1<<20, DropOldest)
+	require.NoError(t, err)
+
+	var sent []string
+	failOn := "b"
+	send := func(_ context.Context, metrics []models.Metrics) error {
+		if metrics[0].ID == failOn {
+			return errors.New("server is down")
+		}
+		sent = append(sent, metrics[0].ID)
+		return nil
+	}
+
+	assert.Error(t, spool.Replay(context.Background(), send))
+	assert.Equal(t, []string{"a"}, sent)
+	assert.Equal(t, 1, spool.Len())
+
+	failOn = ""
+	require.NoError(t, spool.Append(testBatch("d")))
+	require.NoError(t, spool.Replay(context.Background(), send))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, sent)
+	assert.Equal(t, 0, spool.Len())
+}
+
+func TestSpoolReplayDropsRejectedBatch(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 1<<20, DropNewest)
+	require.NoError(t, err)
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, spool.Append(testBatch(id)))
+	}
+
+	var sent []string
+	send := func(_ context.Context, metrics []models.Metrics) error {
+		if metrics[0].ID == "b" {
+			return status.Error(codes.InvalidArgument, "invalid metric")
+		}
+		sent = append(sent, metrics[0].ID)
+		return nil
+	}
+
+	// Отклоненный батч не останавливает очередь.
+	require.NoError(t, spool.Replay(context.Background(), send))
+	assert.Equal(t, []string{"a", "c"}, sent)
+	assert.Equal(t, 0, spool.Len())
+}
+
+func TestSpoolDropPolicy(t *testing.T) {
+	line, err := json.Marshal(testBatch("a"))
+	require.NoError(t, err)
+	batchSize := int64(len(line) + 1)
+
+	tests := []struct {
+		name       string
+		dropPolicy string
+		err        error
+		sent       []string
+	}{
+		{name: "drop oldest", dropPolicy: DropOldest, sent: []string{"b", "c", "d", "e"}},
+		{name: "drop newest", dropPolicy: DropNewest, err: ErrSpoolFull, sent: []string{"a", "b", "c", "d"}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			// По одному батчу в сегменте, в очередь помещается четыре батча.
+			spool, err := NewSpool(t.TempDir(), batchSize*segmentsPerSpool/2, v.dropPolicy)
+			require.NoError(t, err)
+
+			for _, id := range []string{"a", "b", "c", "d"} {
+				require.NoError(t, spool.Append(testBatch(id)))
+			}
+			assert.Equal(t, v.err, spool.Append(testBatch("e")))
+
+			var sent []string
+			require.NoError(t, spool.Replay(context.Background(), func(_ context.Context, metrics []models.Metrics) error {
+				sent = append(sent, metrics[0].ID)
+				return nil
+			}))
+			assert.Equal(t, v.sent, sent)
+		})
+	}
+}
+
+func TestSpoolReplayLargeBatch(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 1<<20, DropOldest)
+	require.NoError(t, err)
+
+	// Батч больше сегмента (maxSize/8) и начального буфера сканера целиком попадает в свой сегмент.
+	large := make([]models.Metrics, 0, 5000)
+	for i := 0; i < 5000; i++ {
+		large = append(large, testBatch("Alloc")...)
+	}
+	require.NoError(t, spool.Append(large))
+	require.NoError(t, spool.Append(testBatch("a")))
+
+	var sent []int
+	require.NoError(t, spool.Replay(context.Background(), func(_ context.Context, metrics []models.Metrics) error {
+		sent = append(sent, len(metrics))
+		return nil
+	}))
+	assert.Equal(t, []int{5000, 1}, sent)
+	assert.Equal(t, 0, spool.Len())
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	grpc_client "go-svc-metrics/internal/agent/grpc"
+	http_client "go-svc-metrics/internal/agent/http"
+	"go-svc-metrics/internal/config"
+	"go-svc-metrics/internal/logger"
+	"go-svc-metrics/models"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Транспорты отправки метрик на сервер.
+const (
+	TransportHTTP       = "http"
+	TransportGRPC       = "grpc"
+	TransportGRPCStream = "grpc-stream"
+)
+
+// fallbackPeriod время, в течение которого после сбоя gRPC метрики отправляются по HTTP.
+const fallbackPeriod = time.Minute
+
+// NewMetricSender возвращает MetricSender для транспорта из конфига.
+func NewMetricSender(agentConfig *config.Config) (MetricSender, error) {
+	transport := TransportGRPC
+	if agentConfig.Transport != nil {
+		transport = *agentConfig.Transport
+	}
+
+	switch transport {
+	case TransportHTTP:
+		return http_client.NewClientAgent(agentConfig)
+	case TransportGRPC, TransportGRPCStream:
+		var grpcClient MetricSender
+		var err error
+		if transport == TransportGRPCStream {
+			grpcClient, err = grpc_client.NewStreamClientAgent(agentConfig)
+		} else {
+			grpcClient, err = grpc_client.NewClientAgent(agentConfig)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if agentConfig.TransportFallback == nil || !*agentConfig.TransportFallback {
+			return grpcClient, nil
+		}
+
+		httpClient, err := http_client.NewClientAgent(agentConfig)
+		if err != nil {
+			grpcClient.ConnClose()
+			return nil, err
+		}
+		return newFailoverSender(grpcClient, httpClient), nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q", transport)
+	}
+}
+
+// failoverSender отправляет метрики через primary, а при его недоступности - через secondary.
+type failoverSender struct {
+	primary        MetricSender
+	secondary      MetricSender
+	mutex          sync.Mutex
+	retryPrimaryAt time.Time
+}
+
+func newFailoverSender(primary, secondary MetricSender) *failoverSender {
+	return &failoverSender{primary: primary, secondary: secondary}
+}
+
+// SendBatchMetrics отправляет батч метрик на сервер.
+func (f *failoverSender) SendBatchMetrics(ctx context.Context, metrics []models.Metrics) error {
+	f.mutex.Lock()
+	usePrimary := time.Now().After(f.retryPrimaryAt)
+	f.mutex.Unlock()
+
+	if usePrimary {
+		err := f.primary.SendBatchMetrics(ctx, metrics)
+		if !isUnavailable(err) {
+			return err
+		}
+
+		logger.Log.Warn("primary transport is unavailable, falling back", zap.Error(err))
+		f.mutex.Lock()
+		f.retryPrimaryAt = time.Now().Add(fallbackPeriod)
+		f.mutex.Unlock()
+	}
+	return f.secondary.SendBatchMetrics(ctx, metrics)
+}
+
+func (f *failoverSender) ConnClose() {
+	f.primary.ConnClose()
+	f.secondary.ConnClose()
+}
+
+// isUnavailable проверяет, что сервер gRPC недоступен по сети.
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// isRetryable проверяет, что батч не отклонен сервером и его стоит отправить позже.
+func isRetryable(err error) bool {
+	var statusErr *http_client.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied:
+		return false
+	}
+	return true
+}
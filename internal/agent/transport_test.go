@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"go-svc-metrics/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeSender struct {
+	err   error
+	calls int
+}
+
+func (f *fakeSender) SendBatchMetrics(_ context.Context, _ []models.Metrics) error {
+	f.calls++
+	return f.err
+}
+
+func (f *fakeSender) ConnClose() {}
+
+func TestFailoverSender(t *testing.T) {
+	tests := []struct {
+		name           string
+		primaryErr     error
+		err            error
+		secondaryCalls int
+	}{
+		{name: "primary is available", primaryErr: nil, secondaryCalls: 0},
+		{name: "primary is unavailable", primaryErr: status.Error(codes.Unavailable, "connection refused"), secondaryCalls: 1},
+		{name: "primary rejects metrics", primaryErr: status.Error(codes.InvalidArgument, "invalid metric"), err: status.Error(codes.InvalidArgument, "invalid metric")},
+		{name: "primary fails with other error", primaryErr: errors.New("marshal error"), err: errors.New("marshal error")},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			primary := &fakeSender{err: v.primaryErr}
+			secondary := &fakeSender{}
+			sender := newFailoverSender(primary, secondary)
+
+			err := sender.SendBatchMetrics(context.Background(), nil)
+			assert.Equal(t, v.err, err)
+			assert.Equal(t, 1, primary.calls)
+			assert.Equal(t, v.secondaryCalls, secondary.calls)
+		})
+	}
+}
+
+func TestFailoverSenderSticksToSecondary(t *testing.T) {
+	primary := &fakeSender{err: status.Error(codes.Unavailable, "connection refused")}
+	secondary := &fakeSender{}
+	sender := newFailoverSender(primary, secondary)
+
+	assert.NoError(t, sender.SendBatchMetrics(context.Background(), nil))
+	assert.NoError(t, sender.SendBatchMetrics(context.Background(), nil))
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 2, secondary.calls)
+}
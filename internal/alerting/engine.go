@@ -0,0 +1,177 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"go-svc-metrics/internal/logger"
+	"go-svc-metrics/internal/service"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Состояния алерта.
+const (
+	StateInactive = "inactive"
+	StatePending  = "pending"
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// errNoData метрика еще не приходила на сервер.
+var errNoData = errors.New("no data")
+
+// MetricReader доступ к метрикам, нужный для вычисления правил.
+type MetricReader interface {
+	GetMetric(ctx context.Context, metric models.Metrics) (models.Metrics, error)
+	QueryRange(ctx context.Context, query service.RangeQuery) ([]models.Sample, error)
+}
+
+// Alert состояние правила после последнего вычисления.
+type Alert struct {
+	Rule       Rule
+	State      string
+	Value      float64
+	ActiveAt   time.Time
+	FiredAt    time.Time
+	ResolvedAt time.Time
+}
+
+// Engine периодически вычисляет правила и хранит состояние алертов.
+type Engine struct {
+	metrics  MetricReader
+	interval time.Duration
+	mutex    sync.Mutex
+	alerts   []Alert
+}
+
+// NewEngine создает Engine для набора правил.
+func NewEngine(metrics MetricReader, rules []Rule, interval time.Duration) *Engine {
+	alerts := make([]Alert, len(rules))
+	for i, rule := range rules {
+		alerts[i] = Alert{Rule: rule, State: StateInactive}
+	}
+	return &Engine{metrics: metrics, interval: interval, alerts: alerts}
+}
+
+// Run вычисляет правила каждые interval до отмены контекста.
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			e.Evaluate(ctx, now)
+		}
+	}
+}
+
+// Alerts возвращает копию текущего состояния алертов.
+func (e *Engine) Alerts() []Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	alerts := make([]Alert, len(e.alerts))
+	copy(alerts, e.alerts)
+	return alerts
+}
+
+// Evaluate вычисляет все правила на момент now и возвращает алерты, сменившие состояние.
+func (e *Engine) Evaluate(ctx context.Context, now time.Time) []Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	changed := make([]Alert, 0)
+	for i := range e.alerts {
+		alert := &e.alerts[i]
+		value, err := e.evaluateRule(ctx, alert.Rule, now)
+		if err != nil && !errors.Is(err, errNoData) {
+			logger.Log.Warn("cannot evaluate alert rule", zap.String("RULE", alert.Rule.Name), zap.Error(err))
+			continue
+		}
+
+		previous := alert.State
+		if err == nil {
+			alert.Value = value
+		}
+		alert.transition(err == nil && alert.Rule.matches(value), now)
+		if alert.State != previous {
+			logger.Log.Info("alert state changed",
+				zap.String("RULE", alert.Rule.Name),
+				zap.String("FROM", previous),
+				zap.String("TO", alert.State),
+				zap.Float64("VALUE", alert.Value),
+			)
+			changed = append(changed, *alert)
+		}
+	}
+	return changed
+}
+
+// transition переводит алерт в следующее состояние.
+func (a *Alert) transition(active bool, now time.Time) {
+	if !active {
+		switch a.State {
+		case StatePending:
+			a.State = StateInactive
+		case StateFiring:
+			a.State = StateResolved
+			a.ResolvedAt = now
+		}
+		return
+	}
+
+	switch a.State {
+	case StateInactive, StateResolved:
+		a.State = StatePending
+		a.ActiveAt = now
+		a.FiredAt = time.Time{}
+		a.ResolvedAt = time.Time{}
+	}
+	if a.State == StatePending && now.Sub(a.ActiveAt) >= a.Rule.For {
+		a.State = StateFiring
+		a.FiredAt = now
+	}
+}
+
+func (e *Engine) evaluateRule(ctx context.Context, rule Rule, now time.Time) (float64, error) {
+	switch rule.Function {
+	case FunctionRate:
+		samples, err := e.metrics.QueryRange(ctx, service.RangeQuery{
+			Metric:      rule.Metric,
+			Start:       now.Add(-rule.Window),
+			End:         now,
+			Step:        rule.Window,
+			Aggregation: service.AggregationIncrease,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		var increase float64
+		for _, sample := range samples {
+			increase += sample.Value
+		}
+		return increase / rule.Window.Seconds(), nil
+	default:
+		metric, err := e.metrics.GetMetric(ctx, rule.Metric)
+		if errors.Is(err, errors2.ErrMetricNotFound) {
+			return 0, errNoData
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		value, ok := metric.SampleValue()
+		if !ok {
+			return 0, errNoData
+		}
+		return value, nil
+	}
+}
@@ -0,0 +1,132 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"go-svc-metrics/internal/config"
+	"go-svc-metrics/internal/service"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMetricReader struct {
+	value   *float64
+	err     error
+	samples []models.Sample
+}
+
+func (f *fakeMetricReader) GetMetric(_ context.Context, metric models.Metrics) (models.Metrics, error) {
+	if f.err != nil {
+		return metric, f.err
+	}
+	if f.value == nil {
+		return metric, errors2.ErrMetricNotFound
+	}
+	metric.Value = f.value
+	return metric, nil
+}
+
+func (f *fakeMetricReader) QueryRange(_ context.Context, _ service.RangeQuery) ([]models.Sample, error) {
+	return f.samples, nil
+}
+
+func TestEngineGaugeRule(t *testing.T) {
+	rulesConfig := []config.AlertRule{
+		{Name: "LowMemory", Metric: "FreeMemory", Type: models.Gauge, Op: "<", Threshold: 1e9},
+	}
+	rulesConfig[0].For.Duration = 2 * time.Minute
+	rules, err := NewRules(rulesConfig)
+	require.NoError(t, err)
+
+	low, high := 5e8, 2e9
+	reader := &fakeMetricReader{}
+	engine := NewEngine(reader, rules, time.Second)
+	start := time.Now()
+
+	tests := []struct {
+		name    string
+		value   *float64
+		at      time.Duration
+		state   string
+		changed bool
+	}{
+		{name: "no data #1", value: nil, at: 0, state: StateInactive},
+		{name: "condition becomes true #2", value: &low, at: 0, state: StatePending, changed: true},
+		{name: "condition holds less than for #3", value: &low, at: time.Minute, state: StatePending},
+		{name: "condition holds for #4", value: &low, at: 2 * time.Minute, state: StateFiring, changed: true},
+		{name: "still firing #5", value: &low, at: 3 * time.Minute, state: StateFiring},
+		{name: "condition becomes false #6", value: &high, at: 4 * time.Minute, state: StateResolved, changed: true},
+		{name: "condition becomes true again #7", value: &low, at: 5 * time.Minute, state: StatePending, changed: true},
+		{name: "condition becomes false while pending #8", value: &high, at: 6 * time.Minute, state: StateInactive, changed: true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			reader.value = v.value
+			changed := engine.Evaluate(context.Background(), start.Add(v.at))
+			assert.Equal(t, v.state, engine.Alerts()[0].State)
+			assert.Equal(t, v.changed, len(changed) == 1)
+		})
+	}
+}
+
+func TestEngineStorageErrorKeepsState(t *testing.T) {
+	rules, err := NewRules([]config.AlertRule{
+		{Name: "LowMemory", Metric: "FreeMemory", Type: models.Gauge, Op: "<", Threshold: 1e9},
+	})
+	require.NoError(t, err)
+
+	low := 5e8
+	reader := &fakeMetricReader{value: &low}
+	engine := NewEngine(reader, rules, time.Second)
+	require.Len(t, engine.Evaluate(context.Background(), time.Now()), 1)
+	assert.Equal(t, StateFiring, engine.Alerts()[0].State)
+
+	// Недоступное хранилище не означает отсутствия данных: алерт не разрешается.
+	reader.err = errors.New("connection refused")
+	assert.Empty(t, engine.Evaluate(context.Background(), time.Now()))
+	assert.Equal(t, StateFiring, engine.Alerts()[0].State)
+}
+
+func TestEngineRateRule(t *testing.T) {
+	rules, err := NewRules([]config.AlertRule{
+		{Name: "AgentDown", Metric: "PollCount", Type: models.Counter, Function: FunctionRate, Op: "==", Threshold: 0},
+	})
+	require.NoError(t, err)
+
+	reader := &fakeMetricReader{}
+	engine := NewEngine(reader, rules, time.Second)
+
+	changed := engine.Evaluate(context.Background(), time.Now())
+	require.Len(t, changed, 1)
+	assert.Equal(t, StateFiring, changed[0].State)
+
+	reader.samples = []models.Sample{{Value: 60}}
+	changed = engine.Evaluate(context.Background(), time.Now())
+	require.Len(t, changed, 1)
+	assert.Equal(t, StateResolved, changed[0].State)
+	assert.InDelta(t, 1.0, changed[0].Value, 1e-9)
+}
+
+func TestNewRulesValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.AlertRule
+	}{
+		{name: "invalid operator", rule: config.AlertRule{Metric: "FreeMemory", Type: models.Gauge, Op: "=~"}},
+		{name: "rate for gauge", rule: config.AlertRule{Metric: "FreeMemory", Type: models.Gauge, Function: FunctionRate, Op: "<"}},
+		{name: "empty metric", rule: config.AlertRule{Type: models.Gauge, Op: "<"}},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			_, err := NewRules([]config.AlertRule{v.rule})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Модуль alerting вычисляет правила алертинга по метрикам сервера.
+package alerting
+
+import (
+	"fmt"
+	"go-svc-metrics/internal/config"
+	"go-svc-metrics/models"
+	"time"
+)
+
+// Функции, применяемые к метрике перед сравнением с порогом.
+const (
+	FunctionValue = "value"
+	FunctionRate  = "rate"
+)
+
+// defaultRateWindow окно для rate, если оно не задано в правиле.
+const defaultRateWindow = time.Minute
+
+// Rule правило: условие над метрикой, которое должно выполняться в течение For.
+type Rule struct {
+	Name      string
+	Metric    models.Metrics
+	Function  string
+	Window    time.Duration
+	Op        string
+	Threshold float64
+	For       time.Duration
+}
+
+// NewRules проверяет правила из конфига и преобразует их в Rule.
+func NewRules(rulesConfig []config.AlertRule) ([]Rule, error) {
+	rules := make([]Rule, 0, len(rulesConfig))
+	names := make(map[string]struct{}, len(rulesConfig))
+
+	for _, ruleConfig := range rulesConfig {
+		rule := Rule{
+			Name:      ruleConfig.Name,
+			Metric:    models.Metrics{ID: ruleConfig.Metric, MType: ruleConfig.Type, Labels: ruleConfig.Labels},
+			Function:  ruleConfig.Function,
+			Window:    ruleConfig.Window.Duration,
+			Op:        ruleConfig.Op,
+			Threshold: ruleConfig.Threshold,
+			For:       ruleConfig.For.Duration,
+		}
+		if rule.Name == "" {
+			rule.Name = rule.Metric.ID
+		}
+		if rule.Function == "" {
+			rule.Function = FunctionValue
+		}
+		if rule.Function == FunctionRate && rule.Window <= 0 {
+			rule.Window = defaultRateWindow
+		}
+
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("alert rule %q: %w", rule.Name, err)
+		}
+		if _, ok := names[rule.Name]; ok {
+			return nil, fmt.Errorf("alert rule %q: duplicate name", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r Rule) validate() error {
+	if r.Metric.ID == "" {
+		return fmt.Errorf("metric is required")
+	}
+	if r.Metric.MType != models.Counter && r.Metric.MType != models.Gauge {
+		return fmt.Errorf("invalid metric type %q", r.Metric.MType)
+	}
+	switch r.Function {
+	case FunctionValue:
+	case FunctionRate:
+		if r.Metric.MType != models.Counter {
+			return fmt.Errorf("rate is supported only for counter")
+		}
+	default:
+		return fmt.Errorf("invalid function %q", r.Function)
+	}
+	if _, ok := operators[r.Op]; !ok {
+		return fmt.Errorf("invalid operator %q", r.Op)
+	}
+	if r.For < 0 {
+		return fmt.Errorf("invalid for duration")
+	}
+	return nil
+}
+
+var operators = map[string]func(value, threshold float64) bool{
+	"<":  func(value, threshold float64) bool { return value < threshold },
+	"<=": func(value, threshold float64) bool { return value <= threshold },
+	">":  func(value, threshold float64) bool { return value > threshold },
+	">=": func(value, threshold float64) bool { return value >= threshold },
+	"==": func(value, threshold float64) bool { return value == threshold },
+	"!=": func(value, threshold float64) bool { return value != threshold },
+}
+
+// matches проверяет условие правила для значения.
+func (r Rule) matches(value float64) bool {
+	return operators[r.Op](value, r.Threshold)
+}
+
+// String возвращает правило в виде "rate of counter PollCount == 0 for 5m0s".
+func (r Rule) String() string {
+	var function string
+	if r.Function == FunctionRate {
+		function = "rate of "
+	}
+	return fmt.Sprintf("%s%s %s %s %g for %s", function, r.Metric.MType, r.Metric.ID, r.Op, r.Threshold, r.For)
+}
@@ -0,0 +1,243 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-svc-metrics/internal/logger"
+	"go-svc-metrics/internal/utils/crypto"
+	"go-svc-metrics/internal/utils/delay"
+	"go-svc-metrics/models"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Значения по умолчанию для повторной отправки уведомлений.
+const (
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+)
+
+// AlertSource источник текущего состояния алертов.
+type AlertSource interface {
+	Alerts() []Alert
+}
+
+// WebhookConfig настройки отправки уведомлений.
+type WebhookConfig struct {
+	URLs           []string
+	Key            string
+	GroupInterval  time.Duration
+	RepeatInterval time.Duration
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// WebhookPayload тело уведомления.
+type WebhookPayload struct {
+	Status string         `json:"status"`
+	Alerts []WebhookAlert `json:"alerts"`
+}
+
+// WebhookAlert алерт в уведомлении.
+type WebhookAlert struct {
+	Name       string        `json:"name"`
+	Status     string        `json:"status"`
+	Rule       string        `json:"rule"`
+	Metric     string        `json:"metric"`
+	Type       string        `json:"type"`
+	Labels     models.Labels `json:"labels,omitempty"`
+	Value      float64       `json:"value"`
+	ActiveAt   time.Time     `json:"active_at"`
+	FiredAt    time.Time     `json:"fired_at"`
+	ResolvedAt *time.Time    `json:"resolved_at,omitempty"`
+}
+
+// notification последнее успешно отправленное уведомление по правилу.
+type notification struct {
+	state   string
+	firedAt time.Time
+	sentAt  time.Time
+}
+
+// WebhookNotifier раз в GroupInterval отправляет сработавшие и разрешенные алерты на webhook.
+// Повторное уведомление о том же сработавшем алерте отправляется не чаще RepeatInterval.
+type WebhookNotifier struct {
+	alerts AlertSource
+	config WebhookConfig
+	client *http.Client
+	mutex  sync.Mutex
+	sent   map[string]map[string]notification
+}
+
+// NewWebhookNotifier создает WebhookNotifier.
+func NewWebhookNotifier(alerts AlertSource, config WebhookConfig) *WebhookNotifier {
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = defaultMaxRetries
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaultInitialBackoff
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = defaultMaxBackoff
+	}
+
+	sent := make(map[string]map[string]notification, len(config.URLs))
+	for _, url := range config.URLs {
+		sent[url] = make(map[string]notification)
+	}
+
+	return &WebhookNotifier{
+		alerts: alerts,
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		sent:   sent,
+	}
+}
+
+// Run отправляет уведомления каждые GroupInterval до отмены контекста.
+func (n *WebhookNotifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.config.GroupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := n.Flush(ctx, now); err != nil {
+				logger.Log.Warn("cannot send alert notification", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Flush отправляет на каждый webhook одну группу алертов, о которых он еще не уведомлен.
+func (n *WebhookNotifier) Flush(ctx context.Context, now time.Time) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	alerts := n.alerts.Alerts()
+	var errs []error
+	for _, url := range n.config.URLs {
+		group := n.collect(url, alerts, now)
+		if len(group) == 0 {
+			continue
+		}
+
+		if err := n.send(ctx, url, group); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+			continue
+		}
+
+		for _, alert := range group {
+			n.sent[url][alert.Rule.Name] = notification{state: alert.State, firedAt: alert.FiredAt, sentAt: now}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// collect выбирает алерты, о которых нужно уведомить webhook.
+func (n *WebhookNotifier) collect(url string, alerts []Alert, now time.Time) []Alert {
+	group := make([]Alert, 0)
+	for _, alert := range alerts {
+		last, notified := n.sent[url][alert.Rule.Name]
+		switch alert.State {
+		case StateFiring:
+			if !notified || last.state != StateFiring || !last.firedAt.Equal(alert.FiredAt) ||
+				now.Sub(last.sentAt) >= n.config.RepeatInterval {
+				group = append(group, alert)
+			}
+		case StateResolved:
+			// Алерт мог сработать и разрешиться между отправками: тогда уведомление о разрешении
+			// с временем срабатывания заменяет неотправленное уведомление о срабатывании.
+			if !notified || last.state != StateResolved || !last.firedAt.Equal(alert.FiredAt) {
+				group = append(group, alert)
+			}
+		}
+	}
+	return group
+}
+
+func (n *WebhookNotifier) send(ctx context.Context, url string, alerts []Alert) error {
+	body, err := json.Marshal(newWebhookPayload(alerts))
+	if err != nil {
+		return err
+	}
+
+	// Задержка удваивается, но не превышает MaxBackoff.
+	nextDelay := delay.NewDelay(n.config.InitialBackoff, func(delay time.Duration) time.Duration {
+		return min(delay*2, n.config.MaxBackoff)
+	})
+	for attempt := 1; ; attempt++ {
+		statusCode, err := n.post(ctx, url, body)
+		if err == nil && statusCode >= http.StatusMultipleChoices {
+			err = fmt.Errorf("unexpected status code %d", statusCode)
+		}
+		// Повторяем только сетевые ошибки и ошибки сервера.
+		retry := err != nil && (statusCode == 0 || statusCode >= http.StatusInternalServerError)
+		if !retry || attempt >= n.config.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(nextDelay()):
+		}
+	}
+}
+
+func (n *WebhookNotifier) post(ctx context.Context, url string, body []byte) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	if n.config.Key != "" {
+		request.Header.Set("HashSHA256", hex.EncodeToString(crypto.GetHash(n.config.Key, body)))
+	}
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	return response.StatusCode, nil
+}
+
+func newWebhookPayload(alerts []Alert) WebhookPayload {
+	payload := WebhookPayload{Status: StateResolved, Alerts: make([]WebhookAlert, 0, len(alerts))}
+	for _, alert := range alerts {
+		if alert.State == StateFiring {
+			payload.Status = StateFiring
+		}
+
+		webhookAlert := WebhookAlert{
+			Name:     alert.Rule.Name,
+			Status:   alert.State,
+			Rule:     alert.Rule.String(),
+			Metric:   alert.Rule.Metric.ID,
+			Type:     alert.Rule.Metric.MType,
+			Labels:   alert.Rule.Metric.Labels,
+			Value:    alert.Value,
+			ActiveAt: alert.ActiveAt,
+			FiredAt:  alert.FiredAt,
+		}
+		if alert.State == StateResolved {
+			resolvedAt := alert.ResolvedAt
+			webhookAlert.ResolvedAt = &resolvedAt
+		}
+		payload.Alerts = append(payload.Alerts, webhookAlert)
+	}
+	return payload
+}
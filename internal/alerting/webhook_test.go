@@ -0,0 +1,161 @@
+package alerting
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"go-svc-metrics/internal/utils/crypto"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticAlertSource struct {
+	alerts []Alert
+}
+
+func (s *staticAlertSource) Alerts() []Alert {
+	return s.alerts
+}
+
+type webhookReceiver struct {
+	mutex    sync.Mutex
+	key      string
+	failures int
+	payloads []WebhookPayload
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	messageMAC, err := hex.DecodeString(req.Header.Get("HashSHA256"))
+	if err != nil || !crypto.ValidMAC(body, messageMAC, r.key) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.payloads = append(r.payloads, payload)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *webhookReceiver) received() []WebhookPayload {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]WebhookPayload(nil), r.payloads...)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	const key = "SecretKey"
+	receiver := &webhookReceiver{key: key, failures: 2}
+	ts := httptest.NewServer(receiver)
+	defer ts.Close()
+
+	rule := Rule{Name: "LowMemory", Function: FunctionValue, Op: "<", Threshold: 1e9}
+	start := time.Now()
+	source := &staticAlertSource{alerts: []Alert{{Rule: rule, State: StateFiring, Value: 5e8, ActiveAt: start, FiredAt: start}}}
+	notifier := NewWebhookNotifier(source, WebhookConfig{
+		URLs:           []string{ts.URL},
+		Key:            key,
+		GroupInterval:  time.Second,
+		RepeatInterval: time.Hour,
+		MaxRetries:     3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	// Первые две попытки получают 503, третья доходит.
+	require.NoError(t, notifier.Flush(ctx, start))
+	require.Len(t, receiver.received(), 1)
+	assert.Equal(t, StateFiring, receiver.received()[0].Status)
+	assert.Equal(t, "LowMemory", receiver.received()[0].Alerts[0].Name)
+
+	// Тот же алерт до истечения RepeatInterval не отправляется повторно.
+	require.NoError(t, notifier.Flush(ctx, start.Add(time.Minute)))
+	assert.Len(t, receiver.received(), 1)
+
+	// После RepeatInterval уведомление повторяется.
+	require.NoError(t, notifier.Flush(ctx, start.Add(2*time.Hour)))
+	assert.Len(t, receiver.received(), 2)
+
+	// Разрешение алерта отправляется один раз.
+	source.alerts[0].State = StateResolved
+	source.alerts[0].ResolvedAt = start.Add(3 * time.Hour)
+	require.NoError(t, notifier.Flush(ctx, start.Add(3*time.Hour)))
+	require.NoError(t, notifier.Flush(ctx, start.Add(4*time.Hour)))
+	require.Len(t, receiver.received(), 3)
+	assert.Equal(t, StateResolved, receiver.received()[2].Status)
+	assert.NotNil(t, receiver.received()[2].Alerts[0].ResolvedAt)
+}
+
+func TestWebhookNotifierResolvedWithinGroupInterval(t *testing.T) {
+	const key = "SecretKey"
+	receiver := &webhookReceiver{key: key}
+	ts := httptest.NewServer(receiver)
+	defer ts.Close()
+
+	start := time.Now()
+	// Алерт сработал и разрешился до первой отправки.
+	source := &staticAlertSource{alerts: []Alert{{
+		Rule:       Rule{Name: "LowMemory"},
+		State:      StateResolved,
+		FiredAt:    start,
+		ResolvedAt: start.Add(time.Second),
+	}}}
+	notifier := NewWebhookNotifier(source, WebhookConfig{
+		URLs:           []string{ts.URL},
+		Key:            key,
+		GroupInterval:  time.Minute,
+		RepeatInterval: time.Hour,
+	})
+	ctx := context.Background()
+
+	require.NoError(t, notifier.Flush(ctx, start.Add(time.Minute)))
+	require.NoError(t, notifier.Flush(ctx, start.Add(2*time.Minute)))
+	require.Len(t, receiver.received(), 1)
+	assert.Equal(t, StateResolved, receiver.received()[0].Status)
+	assert.Equal(t, start.UTC(), receiver.received()[0].Alerts[0].FiredAt.UTC())
+}
+
+func TestWebhookNotifierRetriesExhausted(t *testing.T) {
+	receiver := &webhookReceiver{failures: 10}
+	ts := httptest.NewServer(receiver)
+	defer ts.Close()
+
+	start := time.Now()
+	source := &staticAlertSource{alerts: []Alert{{Rule: Rule{Name: "LowMemory"}, State: StateFiring, FiredAt: start}}}
+	notifier := NewWebhookNotifier(source, WebhookConfig{
+		URLs:           []string{ts.URL},
+		GroupInterval:  time.Second,
+		RepeatInterval: time.Hour,
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	})
+
+	assert.Error(t, notifier.Flush(context.Background(), start))
+	assert.Equal(t, 8, receiver.failures)
+}
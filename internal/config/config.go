@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"os"
 	"strings"
@@ -11,19 +12,32 @@ import (
 )
 
 const (
-	defaultServerAddr      = "localhost:8080"
-	pollIntervalDefault    = "2s"
-	reportIntervalDefault  = "10s"
-	logLevelDefault        = "INFO"
-	StoreIntervalDefault   = "300s"
-	FileStoragePathDefault = "metrics.dump"
-	restoreDefault         = false
-	secretKeyDefault       = "SecretKey"
-	defaultRateLimit       = 3
-	waitDefault            = "15s"
-	realIPDefault          = "192.168.1.22"
-	trustSubnetDefault     = "192.168.1.0/24"
-	defaultAddrGRPC        = "127.0.0.1:8020"
+	defaultServerAddr       = "localhost:8080"
+	pollIntervalDefault     = "2s"
+	reportIntervalDefault   = "10s"
+	logLevelDefault         = "INFO"
+	StoreIntervalDefault    = "300s"
+	FileStoragePathDefault  = "metrics.dump"
+	restoreDefault          = false
+	secretKeyDefault        = "" // раньше "SecretKey": подпись теперь обязательна при заданном ключе, ключ задается явно
+	defaultRateLimit        = 3
+	waitDefault             = "15s"
+	realIPDefault           = "192.168.1.22"
+	trustSubnetDefault      = "192.168.1.0/24"
+	defaultAddrGRPC         = "127.0.0.1:8020"
+	historyRetentionDefault = "1h"
+	historySweepDefault     = "1m"
+	alertIntervalDefault    = "15s"
+	alertGroupDefault       = "30s"
+	alertRepeatDefault      = "4h"
+	transportDefault        = "grpc"
+	spoolMaxSizeDefault     = 64 << 20
+	spoolDropPolicyDefault  = "oldest"
+	walPathDefault          = ""
+	walSyncDefault          = "always"
+	walSyncIntervalDefault  = "1s"
+	kvPathDefault           = "metrics.db"
+	sqlitePathDefault       = "metrics.sqlite"
 )
 
 // NewServerConfig возвращает конфиг для сервера.
@@ -40,13 +54,26 @@ func NewServerConfig() (*Config, error) {
 	fileStoragePath := serverFlagSet.String("f", FileStoragePathDefault, "file storage path")
 	restore := serverFlagSet.Bool("r", restoreDefault, "log level")
 	databaseDsn := serverFlagSet.String("d", "", "Database DSN")
-	key := serverFlagSet.String("k", secretKeyDefault, "sha key")
+	key := serverFlagSet.String("k", secretKeyDefault, "HMAC-SHA256 key, requests without a valid HashSHA256 are rejected; empty disables signing (was SecretKey before)")
 	wait := serverFlagSet.String("z", waitDefault, "wait default")
 	cyptoKey := serverFlagSet.String("crypto-key", "", "CRYPTO KEY")
 	configFilePath := serverFlagSet.String("c", "", "config file")
 	trustSubnet := serverFlagSet.String("t", trustSubnetDefault, "trust Subnet")
 	addrGRPC := serverFlagSet.String("grpc", defaultAddrGRPC, "grpc address")
 	cert := serverFlagSet.String("cert", "", "certifacate")
+	tlsKey := serverFlagSet.String("tls-key", "", "private key of the HTTPS server certificate")
+	caCert := serverFlagSet.String("ca-cert", "", "CA bundle to verify agent client certificates")
+	historyRetention := serverFlagSet.String("history-retention", historyRetentionDefault, "history retention window")
+	historySweepInterval := serverFlagSet.String("history-sweep-interval", historySweepDefault, "interval of removing samples older than the history retention")
+	alertInterval := serverFlagSet.String("alert-interval", alertIntervalDefault, "alert rules evaluation interval")
+	alertGroupInterval := serverFlagSet.String("alert-group-interval", alertGroupDefault, "alert notifications group interval")
+	alertRepeatInterval := serverFlagSet.String("alert-repeat-interval", alertRepeatDefault, "firing alert notifications repeat interval")
+	walPath := serverFlagSet.String("wal-path", walPathDefault, "write-ahead log of the file storage, e.g. metrics.wal; empty (default) disables the log")
+	walSync := serverFlagSet.String("wal-sync", walSyncDefault, "write-ahead log sync mode: always (fsync on every update), batch or interval")
+	walSyncInterval := serverFlagSet.String("wal-sync-interval", walSyncIntervalDefault, "write-ahead log sync interval in interval mode")
+	storage := serverFlagSet.String("storage", "", "storage backend: file, postgres, kv or sqlite, empty selects postgres when the database is available")
+	kvPath := serverFlagSet.String("kv-path", kvPathDefault, "file of the kv storage")
+	sqlitePath := serverFlagSet.String("sqlite-path", sqlitePathDefault, "file of the sqlite storage")
 	err = serverFlagSet.Parse(os.Args[1:])
 	if err != nil {
 		return nil, err
@@ -98,6 +125,69 @@ func NewServerConfig() (*Config, error) {
 	if newConfig.Cert == nil {
 		newConfig.Cert = cert
 	}
+	if newConfig.TLSKey == nil {
+		newConfig.TLSKey = tlsKey
+	}
+	if newConfig.CACert == nil {
+		newConfig.CACert = caCert
+	}
+	if newConfig.HistoryRetention == nil {
+		historyRetentionDuration, err := time.ParseDuration(*historyRetention)
+		if err != nil {
+			return newConfig, err
+		}
+		newConfig.HistoryRetention = &timeConfig{Duration: historyRetentionDuration}
+	}
+	if newConfig.HistorySweep == nil {
+		historySweepDuration, err := time.ParseDuration(*historySweepInterval)
+		if err != nil {
+			return newConfig, err
+		}
+		newConfig.HistorySweep = &timeConfig{Duration: historySweepDuration}
+	}
+	if newConfig.AlertInterval == nil {
+		alertIntervalDuration, err := time.ParseDuration(*alertInterval)
+		if err != nil {
+			return newConfig, err
+		}
+		newConfig.AlertInterval = &timeConfig{Duration: alertIntervalDuration}
+	}
+	if newConfig.AlertGroupInterval == nil {
+		alertGroupDuration, err := time.ParseDuration(*alertGroupInterval)
+		if err != nil {
+			return newConfig, err
+		}
+		newConfig.AlertGroupInterval = &timeConfig{Duration: alertGroupDuration}
+	}
+	if newConfig.AlertRepeatInterval == nil {
+		alertRepeatDuration, err := time.ParseDuration(*alertRepeatInterval)
+		if err != nil {
+			return newConfig, err
+		}
+		newConfig.AlertRepeatInterval = &timeConfig{Duration: alertRepeatDuration}
+	}
+	if newConfig.WALPath == nil {
+		newConfig.WALPath = walPath
+	}
+	if newConfig.WALSync == nil {
+		newConfig.WALSync = walSync
+	}
+	if newConfig.WALSyncInterval == nil {
+		walSyncIntervalDuration, err := time.ParseDuration(*walSyncInterval)
+		if err != nil {
+			return newConfig, err
+		}
+		newConfig.WALSyncInterval = &timeConfig{Duration: walSyncIntervalDuration}
+	}
+	if newConfig.Storage == nil {
+		newConfig.Storage = storage
+	}
+	if newConfig.KVPath == nil {
+		newConfig.KVPath = kvPath
+	}
+	if newConfig.SQLitePath == nil {
+		newConfig.SQLitePath = sqlitePath
+	}
 
 	if *newConfig.ConfigFilePath != "" {
 		err = newConfig.UpdateFromConfig()
@@ -105,9 +195,29 @@ func NewServerConfig() (*Config, error) {
 			return newConfig, err
 		}
 	}
+	if newConfig.HistorySweep == nil || newConfig.HistorySweep.Duration <= 0 {
+		return newConfig, errors.New("history sweep interval must be positive")
+	}
+	if err = newConfig.validateAlerting(); err != nil {
+		return newConfig, err
+	}
 	return newConfig, nil
 }
 
+// validateAlerting проверяет интервалы алертинга, по которым запускаются тикеры.
+func (c *Config) validateAlerting() error {
+	if c.AlertInterval == nil || c.AlertInterval.Duration <= 0 {
+		return errors.New("alert interval must be positive")
+	}
+	if c.AlertGroupInterval == nil || c.AlertGroupInterval.Duration <= 0 {
+		return errors.New("alert group interval must be positive")
+	}
+	if c.AlertRepeatInterval == nil || c.AlertRepeatInterval.Duration < 0 {
+		return errors.New("alert repeat interval must not be negative")
+	}
+	return nil
+}
+
 // NewAgentConfig возвращает конфиг для агента.
 func NewAgentConfig() (*Config, error) {
 	newConfig, err := InitConfig()
@@ -119,12 +229,21 @@ func NewAgentConfig() (*Config, error) {
 	serverAddr := agentFlagSet.String("a", defaultServerAddr, "input endpoint")
 	reportInterval := agentFlagSet.String("r", reportIntervalDefault, "input reportInterval")
 	pollInterval := agentFlagSet.String("p", pollIntervalDefault, "input pollInterval")
-	key := agentFlagSet.String("k", secretKeyDefault, "sha key")
+	key := agentFlagSet.String("k", secretKeyDefault, "HMAC-SHA256 key to sign requests, must match the server key; empty disables signing (was SecretKey before)")
 	rateLimit := agentFlagSet.Uint("l", defaultRateLimit, "rate limit")
 	cyptoKey := agentFlagSet.String("crypto-key", "", "CRYPTO KEY")
 	configFilePath := agentFlagSet.String("c", "", "config file")
 	realIP := agentFlagSet.String("x", realIPDefault, "real ip")
 	addrGRPC := agentFlagSet.String("grpc", defaultAddrGRPC, "grpc address")
+	transport := agentFlagSet.String("transport", transportDefault, "transport: http, grpc or grpc-stream")
+	transportFallback := agentFlagSet.Bool("transport-fallback", false, "fall back to http when grpc is unavailable")
+	caCert := agentFlagSet.String("ca-cert", "", "CA bundle to verify the server certificate")
+	https := agentFlagSet.Bool("https", false, "send metrics over https")
+	clientCert := agentFlagSet.String("client-cert", "", "client certificate for mTLS")
+	clientKey := agentFlagSet.String("client-key", "", "client private key for mTLS")
+	spoolDir := agentFlagSet.String("spool-dir", "", "directory of the send queue, empty disables the queue")
+	spoolMaxSize := agentFlagSet.Int64("spool-max-size", spoolMaxSizeDefault, "max size of the send queue in bytes")
+	spoolDropPolicy := agentFlagSet.String("spool-drop-policy", spoolDropPolicyDefault, "what to drop when the send queue is full: oldest or newest")
 	err = agentFlagSet.Parse(os.Args[1:])
 	if err != nil {
 		return newConfig, err
@@ -164,6 +283,33 @@ func NewAgentConfig() (*Config, error) {
 	if newConfig.AddrGRPC == nil {
 		newConfig.AddrGRPC = addrGRPC
 	}
+	if newConfig.Transport == nil {
+		newConfig.Transport = transport
+	}
+	if newConfig.TransportFallback == nil {
+		newConfig.TransportFallback = transportFallback
+	}
+	if newConfig.CACert == nil {
+		newConfig.CACert = caCert
+	}
+	if newConfig.HTTPS == nil {
+		newConfig.HTTPS = https
+	}
+	if newConfig.ClientCert == nil {
+		newConfig.ClientCert = clientCert
+	}
+	if newConfig.ClientKey == nil {
+		newConfig.ClientKey = clientKey
+	}
+	if newConfig.SpoolDir == nil {
+		newConfig.SpoolDir = spoolDir
+	}
+	if newConfig.SpoolMaxSize == nil {
+		newConfig.SpoolMaxSize = spoolMaxSize
+	}
+	if newConfig.SpoolDropPolicy == nil {
+		newConfig.SpoolDropPolicy = spoolDropPolicy
+	}
 
 	if *newConfig.ConfigFilePath != "" {
 		err = newConfig.UpdateFromConfig()
@@ -187,23 +333,64 @@ func InitConfig() (*Config, error) {
 
 // Config хранит конфиг
 type Config struct {
-	ServerAddr      *string     `env:"ADDRESS" json:"address"`
-	ReportInterval  *timeConfig `env:"REPORT_INTERVAL" json:"report_interval"`
-	PollInterval    *timeConfig `env:"POLL_INTERVAL" json:"poll_interval"`
-	LogLevel        *string     `env:"LOG_LEVEL"`
-	StoreInterval   *timeConfig `env:"STORE_INTERVAL" json:"store_interval"`
-	FileStoragePath *string     `env:"FILE_STORAGE_PATH" json:"store_file"`
-	Restore         *bool       `env:"RESTORE" json:"restore"`
-	DatabaseDsn     *string     `env:"DATABASE_DSN" json:"database_dsn"`
-	Key             *string     `env:"KEY"`
-	RateLimit       *uint       `env:"RATE_LIMIT"`
-	Wait            *timeConfig `env:"WAIT"`
-	CryptoKey       *string     `env:"CRYPTO_KEY" json:"crypto_key"`
-	ConfigFilePath  *string     `env:"CONFIG"`
-	TrustedSubnet   *string     `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
-	RealIP          *string     `env:"REAL_IP"`
-	AddrGRPC        *string     `env:"GRPC address"`
-	Cert            *string     `env:"CERT" json:"cert"`
+	ServerAddr          *string     `env:"ADDRESS" json:"address"`
+	ReportInterval      *timeConfig `env:"REPORT_INTERVAL" json:"report_interval"`
+	PollInterval        *timeConfig `env:"POLL_INTERVAL" json:"poll_interval"`
+	LogLevel            *string     `env:"LOG_LEVEL"`
+	StoreInterval       *timeConfig `env:"STORE_INTERVAL" json:"store_interval"`
+	FileStoragePath     *string     `env:"FILE_STORAGE_PATH" json:"store_file"`
+	Restore             *bool       `env:"RESTORE" json:"restore"`
+	DatabaseDsn         *string     `env:"DATABASE_DSN" json:"database_dsn"`
+	Key                 *string     `env:"KEY"`
+	RateLimit           *uint       `env:"RATE_LIMIT"`
+	Wait                *timeConfig `env:"WAIT"`
+	CryptoKey           *string     `env:"CRYPTO_KEY" json:"crypto_key"`
+	ConfigFilePath      *string     `env:"CONFIG"`
+	TrustedSubnet       *string     `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
+	RealIP              *string     `env:"REAL_IP"`
+	AddrGRPC            *string     `env:"GRPC address"`
+	Cert                *string     `env:"CERT" json:"cert"`
+	TLSKey              *string     `env:"TLS_KEY" json:"tls_key"`
+	HistoryRetention    *timeConfig `env:"HISTORY_RETENTION" json:"history_retention"`
+	HistorySweep        *timeConfig `env:"HISTORY_SWEEP_INTERVAL" json:"history_sweep_interval"`
+	AlertInterval       *timeConfig `env:"ALERT_INTERVAL" json:"alert_interval"`
+	AlertRules          []AlertRule `json:"alert_rules"`
+	AlertWebhooks       []string    `env:"ALERT_WEBHOOKS" json:"alert_webhooks"`
+	AlertGroupInterval  *timeConfig `env:"ALERT_GROUP_INTERVAL" json:"alert_group_interval"`
+	AlertRepeatInterval *timeConfig `env:"ALERT_REPEAT_INTERVAL" json:"alert_repeat_interval"`
+	Transport           *string     `env:"TRANSPORT" json:"transport"`
+	TransportFallback   *bool       `env:"TRANSPORT_FALLBACK" json:"transport_fallback"`
+	CACert              *string     `env:"CA_CERT" json:"ca_cert"`
+	HTTPS               *bool       `env:"HTTPS" json:"https"`
+	ClientCert          *string     `env:"CLIENT_CERT" json:"client_cert"`
+	ClientKey           *string     `env:"CLIENT_KEY" json:"client_key"`
+	SpoolDir            *string     `env:"SPOOL_DIR" json:"spool_dir"`
+	SpoolMaxSize        *int64      `env:"SPOOL_MAX_SIZE" json:"spool_max_size"`
+	SpoolDropPolicy     *string     `env:"SPOOL_DROP_POLICY" json:"spool_drop_policy"`
+	WALPath             *string     `env:"WAL_PATH" json:"wal_path"`
+	WALSync             *string     `env:"WAL_SYNC" json:"wal_sync"`
+	WALSyncInterval     *timeConfig `env:"WAL_SYNC_INTERVAL" json:"wal_sync_interval"`
+	Storage             *string     `env:"STORAGE" json:"storage"`
+	KVPath              *string     `env:"KV_PATH" json:"kv_path"`
+	SQLitePath          *string     `env:"SQLITE_PATH" json:"sqlite_path"`
+}
+
+// AlertRule правило алертинга из файла конфигурации.
+//
+// Example:
+//
+//	{"name": "LowMemory", "metric": "FreeMemory", "type": "gauge", "op": "<", "threshold": 1e9, "for": "2m"}
+//	{"name": "AgentDown", "metric": "PollCount", "type": "counter", "function": "rate", "window": "1m", "op": "==", "threshold": 0, "for": "5m"}
+type AlertRule struct {
+	Name      string            `json:"name"`
+	Metric    string            `json:"metric"`
+	Type      string            `json:"type"`
+	Labels    map[string]string `json:"labels"`
+	Function  string            `json:"function"`
+	Window    timeConfig        `json:"window"`
+	Op        string            `json:"op"`
+	Threshold float64           `json:"threshold"`
+	For       timeConfig        `json:"for"`
 }
 
 type timeConfig struct {
@@ -248,6 +435,7 @@ func InitDefaultEnv() error {
 		"STORE_INTERVAL":    StoreIntervalDefault,
 		"FILE_STORAGE_PATH": FileStoragePathDefault,
 		"RESTORE":           "false",
+		"HISTORY_RETENTION": historyRetentionDefault,
 	}
 	for k, v := range envDefaults {
 		err := os.Setenv(k, v)
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAlerting(t *testing.T) {
+	interval := func(d time.Duration) *timeConfig { return &timeConfig{Duration: d} }
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "valid #1", config: Config{AlertInterval: interval(time.Second), AlertGroupInterval: interval(time.Second), AlertRepeatInterval: interval(0)}},
+		{name: "zero alert interval #2", config: Config{AlertInterval: interval(0), AlertGroupInterval: interval(time.Second), AlertRepeatInterval: interval(0)}, wantErr: true},
+		{name: "negative group interval #3", config: Config{AlertInterval: interval(time.Second), AlertGroupInterval: interval(-time.Second), AlertRepeatInterval: interval(0)}, wantErr: true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			err := v.config.validateAlerting()
+			assert.Equal(t, v.wantErr, err != nil)
+		})
+	}
+}
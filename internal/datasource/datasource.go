@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 // NewDatabase возвращает подключение к БД и накатывает новые миграции.
@@ -18,7 +19,28 @@ func NewDatabase(databaseURI string) (*sql.DB, error) {
 		return nil, err
 	}
 
-	if err := upMigrations(db); err != nil {
+	if err := upMigrations(db, dialectPostgres); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// NewSQLiteDatabase открывает файл SQLite и накатывает новые миграции.
+// SQLite допускает одного писателя, поэтому используется одно соединение.
+func NewSQLiteDatabase(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=synchronous(FULL)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := upMigrations(db, dialectSQLite); err != nil {
+		db.Close()
 		return nil, err
 	}
 	return db, nil
@@ -3,21 +3,35 @@ package datasource
 import (
 	"database/sql"
 	"embed"
+	"path"
 
 	"github.com/pressly/goose/v3"
 )
 
-//go:embed migrations/*.sql
+// Диалекты миграций, у каждого свой каталог в migrations.
+const (
+	dialectPostgres = "postgres"
+	dialectSQLite   = "sqlite3"
+)
+
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
 var embedMigrations embed.FS
 
-func upMigrations(db *sql.DB) error {
+// migrationDirs каталоги миграций диалектов.
+// Версии миграций совпадают во всех диалектах, различается только SQL.
+var migrationDirs = map[string]string{
+	dialectPostgres: "postgres",
+	dialectSQLite:   "sqlite",
+}
+
+func upMigrations(db *sql.DB, dialect string) error {
 	goose.SetBaseFS(embedMigrations)
 
-	if err := goose.SetDialect("postgres"); err != nil {
+	if err := goose.SetDialect(dialect); err != nil {
 		return err
 	}
 
-	if err := goose.Up(db, "migrations"); err != nil {
+	if err := goose.Up(db, path.Join("migrations", migrationDirs[dialect])); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE "metric_table" ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '';
+ALTER TABLE "metric_table" DROP CONSTRAINT IF EXISTS metric_table_pkey;
+ALTER TABLE "metric_table" ADD PRIMARY KEY (name_id, type, labels);
+
+-- +goose Down
+ALTER TABLE "metric_table" DROP CONSTRAINT IF EXISTS metric_table_pkey;
+DELETE FROM "metric_table" WHERE labels <> '';
+ALTER TABLE "metric_table" DROP COLUMN IF EXISTS labels;
+ALTER TABLE "metric_table" ADD PRIMARY KEY (name_id);
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS "metric_table" (
+    name_id TEXT PRIMARY KEY NOT NULL,
+    type TEXT NOT NULL,
+    value REAL DEFAULT 0,
+    delta INTEGER DEFAULT 0
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS "metric_table";
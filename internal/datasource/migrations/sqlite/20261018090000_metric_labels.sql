@@ -0,0 +1,24 @@
+-- +goose Up
+-- SQLite не меняет первичный ключ через ALTER TABLE, таблица пересоздается.
+CREATE TABLE "metric_table_labels" (
+    name_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    labels TEXT NOT NULL DEFAULT '',
+    value REAL DEFAULT 0,
+    delta INTEGER DEFAULT 0,
+    PRIMARY KEY (name_id, type, labels)
+);
+INSERT INTO "metric_table_labels" (name_id, type, value, delta) SELECT name_id, type, value, delta FROM "metric_table";
+DROP TABLE "metric_table";
+ALTER TABLE "metric_table_labels" RENAME TO "metric_table";
+
+-- +goose Down
+CREATE TABLE "metric_table_name" (
+    name_id TEXT PRIMARY KEY NOT NULL,
+    type TEXT NOT NULL,
+    value REAL DEFAULT 0,
+    delta INTEGER DEFAULT 0
+);
+INSERT INTO "metric_table_name" (name_id, type, value, delta) SELECT name_id, type, value, delta FROM "metric_table" WHERE labels = '';
+DROP TABLE "metric_table";
+ALTER TABLE "metric_table_name" RENAME TO "metric_table";
@@ -0,0 +1,14 @@
+-- +goose Up
+-- ts хранится в наносекундах Unix.
+CREATE TABLE IF NOT EXISTS "metric_history" (
+    name_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    labels TEXT NOT NULL DEFAULT '',
+    ts INTEGER NOT NULL,
+    value REAL NOT NULL
+);
+CREATE INDEX IF NOT EXISTS metric_history_metric_ts_idx ON "metric_history" (name_id, type, labels, ts);
+CREATE INDEX IF NOT EXISTS metric_history_ts_idx ON "metric_history" (ts);
+
+-- +goose Down
+DROP TABLE IF EXISTS "metric_history";
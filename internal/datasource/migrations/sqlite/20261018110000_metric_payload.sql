@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE "metric_table" ADD COLUMN payload TEXT;
+
+-- +goose Down
+DELETE FROM "metric_table" WHERE payload IS NOT NULL;
+ALTER TABLE "metric_table" DROP COLUMN payload;
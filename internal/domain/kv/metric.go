@@ -0,0 +1,253 @@
+// Модуль kv представляет реализацию интерфейса для работы со встроенным хранилищем bbolt.
+package kv
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"math"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// metricsBucket метрики по ключу Metrics.Key в виде JSON.
+	metricsBucket = []byte("metrics")
+	// historyBucket вложенные бакеты истории метрик: ключ - время в наносекундах, значение - float64.
+	historyBucket = []byte("history")
+)
+
+// openTimeout время ожидания блокировки файла, если его держит другой процесс.
+const openTimeout = time.Second
+
+// MetricKVRepository хранит метрики во встроенном транзакционном хранилище.
+// Каждый батч применяется в одной транзакции и сохраняется на диск до ответа.
+type MetricKVRepository struct {
+	db               *bolt.DB
+	historyRetention time.Duration
+}
+
+// NewMetricKVRepository открывает хранилище path и создает бакеты.
+func NewMetricKVRepository(path string, historyRetention time.Duration) (*MetricKVRepository, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(metricsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(historyBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &MetricKVRepository{db: db, historyRetention: historyRetention}, nil
+}
+
+// Ping проверяет, что хранилище открыто.
+func (m *MetricKVRepository) Ping() error {
+	return storageError(m.db.View(func(*bolt.Tx) error { return nil }))
+}
+
+func (m *MetricKVRepository) Close() error { return m.db.Close() }
+
+// DumpMetricsByInterval ничего не делает: транзакции сохраняются на диск при коммите.
+func (m *MetricKVRepository) DumpMetricsByInterval(_ context.Context) error {
+	return nil
+}
+
+// UpdateMetrics применяет батч в одной транзакции: ошибка любой метрики откатывает весь батч.
+func (m *MetricKVRepository) UpdateMetrics(_ context.Context, metrics []models.Metrics) ([]models.Metrics, error) {
+	updated := make([]models.Metrics, 0, len(metrics))
+	err := m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(metricsBucket)
+		now := time.Now()
+		for _, metric := range metrics {
+			key := []byte(metric.Key())
+			current := metric.Clone()
+			if data := bucket.Get(key); data != nil {
+				// Сохраненное состояние читается в пустую метрику, иначе JSON ляжет поверх входящей.
+				current = models.Metrics{}
+				if err := json.Unmarshal(data, &current); err != nil {
+					return err
+				}
+				if err := current.Merge(metric); err != nil {
+					return err
+				}
+			} else if err := checkValue(metric); err != nil {
+				return err
+			}
+
+			data, err := json.Marshal(current)
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put(key, data); err != nil {
+				return err
+			}
+			if err = m.appendSample(tx, key, current, now); err != nil {
+				return err
+			}
+			updated = append(updated, current.Clone())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, storageError(err)
+	}
+	return updated, nil
+}
+
+// checkValue проверяет значение новой метрики, для существующих это делает Merge.
+func checkValue(metric models.Metrics) error {
+	if (metric.MType == models.Counter && metric.Delta == nil) ||
+		(metric.MType == models.Gauge && metric.Value == nil) {
+		return errors2.ErrMissingMetricValue
+	}
+	return nil
+}
+
+// appendSample добавляет значение в историю метрики. Устаревшие значения удаляет ExpireHistory.
+func (m *MetricKVRepository) appendSample(tx *bolt.Tx, key []byte, metric models.Metrics, now time.Time) error {
+	if m.historyRetention <= 0 {
+		return nil
+	}
+	value, ok := metric.SampleValue()
+	if !ok {
+		return nil
+	}
+
+	history, err := tx.Bucket(historyBucket).CreateBucketIfNotExists(key)
+	if err != nil {
+		return err
+	}
+	return history.Put(encodeTimestamp(now), encodeValue(value))
+}
+
+// ExpireHistory удаляет устаревшие значения истории всех метрик,
+// в том числе тех, что перестали обновляться. Пустые бакеты истории удаляются.
+func (m *MetricKVRepository) ExpireHistory(_ context.Context, now time.Time) error {
+	cutoff := encodeTimestamp(now.Add(-m.historyRetention))
+	err := m.db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(historyBucket)
+		// Бакеты нельзя удалять во время обхода, поэтому сначала собираем ключи.
+		var keys [][]byte
+		if err := root.ForEachBucket(func(key []byte) error {
+			keys = append(keys, append([]byte(nil), key...))
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			history := root.Bucket(key)
+			cursor := history.Cursor()
+			// После Delete курсор переставляется на начало: Next после удаления может пропустить ключ.
+			for ts, _ := cursor.First(); ts != nil && string(ts) <= string(cutoff); ts, _ = cursor.First() {
+				if err := cursor.Delete(); err != nil {
+					return err
+				}
+			}
+			if ts, _ := cursor.First(); ts == nil {
+				if err := root.DeleteBucket(key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return storageError(err)
+}
+
+func (m *MetricKVRepository) GetMetric(_ context.Context, metric models.Metrics) (models.Metrics, error) {
+	var stored models.Metrics
+	err := m.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metricsBucket).Get([]byte(metric.Key()))
+		if data == nil {
+			return errors2.ErrMetricNotFound
+		}
+		return json.Unmarshal(data, &stored)
+	})
+	if err != nil {
+		return metric, storageError(err)
+	}
+	return stored, nil
+}
+
+func (m *MetricKVRepository) GetAllMetrics(_ context.Context) ([]models.Metrics, error) {
+	metrics := make([]models.Metrics, 0)
+	err := m.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(metricsBucket).ForEach(func(_, data []byte) error {
+			var metric models.Metrics
+			if err := json.Unmarshal(data, &metric); err != nil {
+				return err
+			}
+			metrics = append(metrics, metric)
+			return nil
+		})
+	})
+	if err != nil {
+		return metrics, storageError(err)
+	}
+	return metrics, nil
+}
+
+func (m *MetricKVRepository) GetMetricHistory(_ context.Context, metric models.Metrics, from, to time.Time) ([]models.Sample, error) {
+	samples := make([]models.Sample, 0)
+	err := m.db.View(func(tx *bolt.Tx) error {
+		history := tx.Bucket(historyBucket).Bucket([]byte(metric.Key()))
+		if history == nil {
+			return nil
+		}
+
+		end := encodeTimestamp(to)
+		cursor := history.Cursor()
+		for ts, value := cursor.Seek(encodeTimestamp(from)); ts != nil && string(ts) <= string(end); ts, value = cursor.Next() {
+			samples = append(samples, models.Sample{Timestamp: decodeTimestamp(ts), Value: decodeValue(value)})
+		}
+		return nil
+	})
+	if err != nil {
+		return samples, storageError(err)
+	}
+	return samples, nil
+}
+
+// encodeTimestamp кодирует время в big-endian, чтобы порядок ключей совпадал с порядком времени.
+// Время до 1970 года в истории не встречается.
+func encodeTimestamp(ts time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(max(ts.UnixNano(), 0)))
+	return key
+}
+
+func decodeTimestamp(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key)))
+}
+
+func encodeValue(value float64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, math.Float64bits(value))
+	return data
+}
+
+func decodeValue(data []byte) float64 {
+	return math.Float64frombits(binary.BigEndian.Uint64(data))
+}
+
+// storageError помечает ошибки закрытого или заблокированного хранилища как ErrStorageUnavailable.
+func storageError(err error) error {
+	if errors.Is(err, bolt.ErrDatabaseNotOpen) || errors.Is(err, bolt.ErrTimeout) {
+		return fmt.Errorf("%w: %w", errors2.ErrStorageUnavailable, err)
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package kv
+
+import (
+	"context"
+	"go-svc-metrics/internal/sketch"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func counter(id string, delta int64) models.Metrics {
+	return models.Metrics{ID: id, MType: models.Counter, Delta: &delta}
+}
+
+func TestKVRepositoryUpdateMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.db")
+	ctx := context.Background()
+
+	repo, err := NewMetricKVRepository(path, time.Hour)
+	require.NoError(t, err)
+	_, err = repo.UpdateMetrics(ctx, []models.Metrics{counter("PollCount", 2)})
+	require.NoError(t, err)
+	updated, err := repo.UpdateMetrics(ctx, []models.Metrics{counter("PollCount", 3), counter("PollCount", 1)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *updated[0].Delta)
+	assert.Equal(t, int64(6), *updated[1].Delta)
+
+	// Батч с ошибкой откатывается целиком.
+	_, err = repo.UpdateMetrics(ctx, []models.Metrics{counter("PollCount", 1), {ID: "Alloc", MType: models.Gauge}})
+	assert.ErrorIs(t, err, errors2.ErrMissingMetricValue)
+	require.NoError(t, repo.Close())
+
+	// Состояние сохраняется после переоткрытия.
+	repo, err = NewMetricKVRepository(path, time.Hour)
+	require.NoError(t, err)
+	defer repo.Close()
+	metric, err := repo.GetMetric(ctx, models.Metrics{ID: "PollCount", MType: models.Counter})
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), *metric.Delta)
+
+	_, err = repo.GetMetric(ctx, models.Metrics{ID: "Alloc", MType: models.Gauge})
+	assert.ErrorIs(t, err, errors2.ErrMetricNotFound)
+
+	metrics, err := repo.GetAllMetrics(ctx)
+	require.NoError(t, err)
+	assert.Len(t, metrics, 1)
+
+	// Значение сохраняется в историю один раз за батч.
+	samples, err := repo.GetMetricHistory(ctx, metric, time.Now().Add(-time.Minute), time.Now())
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, 6.0, samples[1].Value)
+}
+
+func TestKVRepositoryClosed(t *testing.T) {
+	repo, err := NewMetricKVRepository(filepath.Join(t.TempDir(), "metrics.db"), 0)
+	require.NoError(t, err)
+	require.NoError(t, repo.Ping())
+	require.NoError(t, repo.Close())
+
+	assert.ErrorIs(t, repo.Ping(), errors2.ErrStorageUnavailable)
+}
+
+func summary(t *testing.T, values ...float64) models.Metrics {
+	s, err := sketch.New(sketch.DefaultRelativeAccuracy)
+	require.NoError(t, err)
+	for _, value := range values {
+		s.Add(value)
+	}
+	return models.Metrics{ID: "GCPause", MType: models.Summary, Sketch: s}
+}
+
+func set(t *testing.T, members ...string) models.Metrics {
+	hll, err := sketch.NewHyperLogLog(sketch.DefaultPrecision)
+	require.NoError(t, err)
+	for _, member := range members {
+		hll.Add(member)
+	}
+	return models.Metrics{ID: "Users", MType: models.Set, HLL: hll}
+}
+
+func TestKVRepositoryMergeDistributions(t *testing.T) {
+	repo, err := NewMetricKVRepository(filepath.Join(t.TempDir(), "metrics.db"), 0)
+	require.NoError(t, err)
+	defer repo.Close()
+	ctx := context.Background()
+
+	_, err = repo.UpdateMetrics(ctx, []models.Metrics{summary(t, 1), set(t, "a", "b")})
+	require.NoError(t, err)
+	updated, err := repo.UpdateMetrics(ctx, []models.Metrics{summary(t, 100, 0), set(t, "b", "c")})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), updated[0].Sketch.Count())
+	assert.Equal(t, uint64(3), updated[1].Cardinality())
+
+	metric, err := repo.GetMetric(ctx, models.Metrics{ID: "GCPause", MType: models.Summary})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), metric.Sketch.Count())
+	metric, err = repo.GetMetric(ctx, models.Metrics{ID: "Users", MType: models.Set})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), metric.Cardinality())
+}
+
+func TestKVRepositoryExpireHistory(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewMetricKVRepository(filepath.Join(t.TempDir(), "metrics.db"), time.Hour)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	_, err = repo.UpdateMetrics(ctx, []models.Metrics{counter("PollCount", 1)})
+	require.NoError(t, err)
+	_, err = repo.UpdateMetrics(ctx, []models.Metrics{counter("PollCount", 1), counter("Stopped", 1)})
+	require.NoError(t, err)
+
+	// Метрика, которая перестала обновляться, тоже очищается.
+	now := time.Now()
+	require.NoError(t, repo.ExpireHistory(ctx, now.Add(2*time.Hour)))
+	for _, id := range []string{"PollCount", "Stopped"} {
+		samples, err := repo.GetMetricHistory(ctx, counter(id, 0), now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, samples)
+	}
+
+	// Значения в пределах периода хранения остаются.
+	_, err = repo.UpdateMetrics(ctx, []models.Metrics{counter("PollCount", 1)})
+	require.NoError(t, err)
+	require.NoError(t, repo.ExpireHistory(ctx, time.Now()))
+	samples, err := repo.GetMetricHistory(ctx, counter("PollCount", 0), now.Add(-time.Hour), time.Now())
+	require.NoError(t, err)
+	assert.Len(t, samples, 1)
+}
@@ -2,145 +2,328 @@
 package local
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
-	"fmt"
 	"go-svc-metrics/internal/config"
+	"go-svc-metrics/internal/logger"
+	errors2 "go-svc-metrics/internal/utils/errors"
 	"go-svc-metrics/models"
 	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
+
+	"go.uber.org/zap"
 )
 
 type MetricLocalRepository struct {
-	Metrics       map[string]models.Metrics
-	mutex         sync.Mutex
-	file          *os.File
-	storeInterval time.Duration
-	scanner       *bufio.Scanner
+	Metrics          map[string]models.Metrics
+	history          map[string][]models.Sample
+	mutex            sync.Mutex
+	path             string
+	generation       uint64
+	dumpMutex        sync.Mutex
+	storeInterval    time.Duration
+	historyRetention time.Duration
+	wal              *WAL
 }
 
 func NewMetricLocalRepository(config *config.Config) (*MetricLocalRepository, error) {
-	file, err := os.OpenFile(*config.FileStoragePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		return nil, err
-	}
 	localStorage := MetricLocalRepository{
 		Metrics:       make(map[string]models.Metrics),
-		file:          file,
-		scanner:       bufio.NewScanner(file),
+		history:       make(map[string][]models.Sample),
+		path:          *config.FileStoragePath,
 		storeInterval: config.StoreInterval.Duration,
 	}
-	if *config.Restore {
-		err = localStorage.RestoreMetrics()
+	if config.HistoryRetention != nil {
+		localStorage.historyRetention = config.HistoryRetention.Duration
+	}
+
+	// Без восстановления поврежденный снимок не мешает старту: следующий дамп его заменит.
+	header, metrics, history, err := readSnapshot(localStorage.path)
+	if err != nil && *config.Restore {
+		return nil, err
+	}
+	localStorage.generation = header.Generation
+
+	if config.WALPath != nil && *config.WALPath != "" {
+		syncMode, syncInterval := WALSyncAlways, time.Duration(0)
+		if config.WALSync != nil {
+			syncMode = *config.WALSync
+		}
+		if config.WALSyncInterval != nil {
+			syncInterval = config.WALSyncInterval.Duration
+		}
+		localStorage.wal, err = OpenWAL(*config.WALPath, syncMode, syncInterval, header.Generation)
 		if err != nil {
 			return nil, err
 		}
+		// Журнал новее снимка, если сбой произошел между ротацией журнала и записью снимка.
+		localStorage.generation = max(localStorage.generation, localStorage.wal.Generation())
+	}
+
+	if *config.Restore {
+		for _, metric := range metrics {
+			localStorage.Metrics[metric.Key()] = metric
+		}
+		// Устаревшие значения удалит ExpireHistory.
+		if localStorage.historyRetention > 0 && history != nil {
+			localStorage.history = history
+		}
+		// Журналы содержат обновления, принятые после последнего снимка.
+		if localStorage.wal != nil {
+			err = localStorage.wal.Replay(header.Generation, localStorage.applyWAL)
+		}
+	} else if localStorage.wal != nil {
+		err = localStorage.wal.Reset(localStorage.generation)
+	}
+	if err != nil {
+		return nil, err
 	}
 	return &localStorage, nil
 }
 
+// applyWAL применяет запись журнала при восстановлении.
+func (m *MetricLocalRepository) applyWAL(metrics []models.Metrics) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, metric := range metrics {
+		m.Metrics[metric.Key()] = metric
+	}
+}
+
 func (m *MetricLocalRepository) UpdateMetrics(_ context.Context, metricsToUpdate []models.Metrics) ([]models.Metrics, error) {
+	// Батч проверяется до изменения хранилища, чтобы не применить его частично.
 	for _, metricToUpdate := range metricsToUpdate {
-		m.mutex.Lock()
-		switch metricToUpdate.MType {
-		case models.Gauge:
-			m.Metrics[metricToUpdate.ID] = metricToUpdate
-		case models.Counter:
-			_, ok := m.Metrics[metricToUpdate.ID]
-			if !ok {
-				m.Metrics[metricToUpdate.ID] = metricToUpdate
-			} else {
-				*m.Metrics[metricToUpdate.ID].Delta += *metricToUpdate.Delta
-			}
-			metricToUpdate.Delta = m.Metrics[metricToUpdate.ID].Delta
-		}
-		m.mutex.Unlock()
+		if (metricToUpdate.MType == models.Counter && metricToUpdate.Delta == nil) ||
+			(metricToUpdate.MType == models.Gauge && metricToUpdate.Value == nil) {
+			return nil, errors2.ErrMissingMetricValue
+		}
+	}
+
+	m.mutex.Lock()
+
+	// Обновления сначала собираются отдельно: несовместимая гистограмма отклоняет весь батч.
+	staged := make(map[string]models.Metrics, len(metricsToUpdate))
+	for i, metricToUpdate := range metricsToUpdate {
+		key := metricToUpdate.Key()
+		current, ok := staged[key]
+		if !ok {
+			current, ok = m.Metrics[key]
+		}
+		if !ok {
+			current = metricToUpdate.Clone()
+		} else if err := current.Merge(metricToUpdate); err != nil {
+			m.mutex.Unlock()
+			return nil, err
+		}
+		staged[key] = current
+		metricsToUpdate[i] = current.Clone()
+	}
+
+	// Запись в журнал идет под mutex, чтобы порядок записей совпадал с порядком обновлений.
+	var seq uint64
+	if m.wal != nil {
+		var err error
+		if seq, err = m.wal.Append(metricsToUpdate); err != nil {
+			m.mutex.Unlock()
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	for key, metric := range staged {
+		m.Metrics[key] = metric
+		m.appendSample(key, metric, now)
+	}
+	m.mutex.Unlock()
+
+	if m.wal != nil {
+		if err := m.wal.Wait(seq); err != nil {
+			return nil, err
+		}
+	}
+	// Синхронный режим: снимок сохраняется до ответа на обновление.
+	// Обновление уже применено, поэтому ошибка снимка не возвращается клиенту: повтор запроса учел бы счетчики дважды.
+	// Следующий снимок сохранит это обновление вместе с остальными.
+	if m.storeInterval <= 0 {
+		if err := m.DumpMetrics(); err != nil {
+			logger.Log.Error("synchronous metrics dump failed", zap.Error(err))
+		}
 	}
 	return metricsToUpdate, nil
 }
 
+// GetAllMetrics возвращает копии метрик, чтобы их можно было читать параллельно с обновлениями.
 func (m *MetricLocalRepository) GetAllMetrics(_ context.Context) ([]models.Metrics, error) {
-	metrics := make([]models.Metrics, 0)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	metrics := make([]models.Metrics, 0, len(m.Metrics))
 	for _, metric := range m.Metrics {
-		metrics = append(metrics, metric)
+		metrics = append(metrics, metric.Clone())
 	}
 	return metrics, nil
 }
 
 func (m *MetricLocalRepository) GetMetric(_ context.Context, metric models.Metrics) (models.Metrics, error) {
 	m.mutex.Lock()
-	value, ok := m.Metrics[metric.ID]
+	value, ok := m.Metrics[metric.Key()]
 	m.mutex.Unlock()
 	if !ok {
-		return models.Metrics{}, fmt.Errorf("metric not found")
+		return models.Metrics{}, errors2.ErrMetricNotFound
 	}
 	return value, nil
 }
 
-func (m *MetricLocalRepository) Ping() error {
-	return fmt.Errorf("is local storage (file)")
+func (m *MetricLocalRepository) GetMetricHistory(_ context.Context, metric models.Metrics, from, to time.Time) ([]models.Sample, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	samples := m.history[metric.Key()]
+	start := sort.Search(len(samples), func(i int) bool { return !samples[i].Timestamp.Before(from) })
+	end := sort.Search(len(samples), func(i int) bool { return samples[i].Timestamp.After(to) })
+	if start >= end {
+		return []models.Sample{}, nil
+	}
+
+	result := make([]models.Sample, end-start)
+	copy(result, samples[start:end])
+	return result, nil
 }
 
-func (m *MetricLocalRepository) Close() error {
-	return m.file.Close()
+// appendSample добавляет значение в историю. Устаревшие значения удаляет ExpireHistory.
+// Вызывается под mutex.
+func (m *MetricLocalRepository) appendSample(key string, metric models.Metrics, now time.Time) {
+	if m.historyRetention <= 0 {
+		return
+	}
+	value, ok := metric.SampleValue()
+	if !ok {
+		return
+	}
+	m.history[key] = append(m.history[key], models.Sample{Timestamp: now, Value: value})
 }
 
-func (m *MetricLocalRepository) RestoreMetrics() error {
+// ExpireHistory удаляет устаревшие значения истории всех метрик,
+// в том числе тех, что перестали обновляться.
+func (m *MetricLocalRepository) ExpireHistory(_ context.Context, now time.Time) error {
 	m.mutex.Lock()
-	for m.scanner.Scan() {
-		data := m.scanner.Bytes()
-		metric := models.Metrics{}
-		err := json.Unmarshal(data, &metric)
-		if err != nil {
-			return err
-		}
+	defer m.mutex.Unlock()
 
-		m.Metrics[metric.ID] = metric
+	cutoff := now.Add(-m.historyRetention)
+	for key, samples := range m.history {
+		expired := sort.Search(len(samples), func(i int) bool { return samples[i].Timestamp.After(cutoff) })
+		if expired == len(samples) {
+			delete(m.history, key)
+			continue
+		}
+		if expired > 0 {
+			// Копия освобождает массив с устаревшими значениями.
+			m.history[key] = append([]models.Sample(nil), samples[expired:]...)
+		}
 	}
-	m.mutex.Unlock()
+	return nil
+}
 
-	if err := m.scanner.Err(); err != nil {
-		return err
+// Ping проверяет, что каталог хранилища доступен.
+func (m *MetricLocalRepository) Ping() error {
+	_, err := os.Stat(filepath.Dir(m.path))
+	return err
+}
+
+func (m *MetricLocalRepository) Close() error {
+	if m.wal != nil {
+		return m.wal.Close()
 	}
 	return nil
 }
 
-func (m *MetricLocalRepository) WriteMetric(metric *models.Metrics) error {
-	data, err := json.Marshal(&metric)
+// RestoreMetrics загружает метрики из последнего снимка.
+func (m *MetricLocalRepository) RestoreMetrics() error {
+	_, metrics, history, err := readSnapshot(m.path)
 	if err != nil {
 		return err
 	}
-	data = append(data, '\n')
-	_, err = m.file.Write(data)
-	return err
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, metric := range metrics {
+		m.Metrics[metric.Key()] = metric
+	}
+	if m.historyRetention > 0 && history != nil {
+		m.history = history
+	}
+	return nil
 }
 
+// DumpMetricsByInterval сохраняет снимок каждые storeInterval до отмены ctx, затем сохраняет последний снимок.
+// Ошибка периодического сохранения не останавливает цикл: следующий снимок включает те же данные.
+// При storeInterval = 0 снимок сохраняется при каждом обновлении, здесь остается только последний.
 func (m *MetricLocalRepository) DumpMetricsByInterval(ctx context.Context) error {
+	if m.storeInterval <= 0 {
+		<-ctx.Done()
+		return m.flush()
+	}
+
 	storeIntervalTicker := time.NewTicker(m.storeInterval)
 	defer storeIntervalTicker.Stop()
-	select {
-	case <-storeIntervalTicker.C:
-		return m.DumpMetrics()
-	case <-ctx.Done():
-		return m.DumpMetrics()
+	for {
+		select {
+		case <-storeIntervalTicker.C:
+			m.flush()
+		case <-ctx.Done():
+			return m.flush()
+		}
 	}
 }
 
+// flush сохраняет снимок и логирует результат и длительность.
+func (m *MetricLocalRepository) flush() error {
+	start := time.Now()
+	err := m.DumpMetrics()
+	if err != nil {
+		logger.Log.Error("metrics dump failed", zap.Duration("duration", time.Since(start)), zap.Error(err))
+		return err
+	}
+	logger.Log.Info("metrics dumped", zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// DumpMetrics атомарно заменяет снимок метрик снимком следующего поколения.
+// Журнал ротируется вместе с копированием метрик, а ротированные журналы удаляются,
+// только когда снимок записан на диск.
+// История значений тоже сохраняется в снимке. Журнал хранит только состояние метрик,
+// поэтому после сбоя в истории нет значений, принятых после последнего снимка.
 func (m *MetricLocalRepository) DumpMetrics() error {
-	metricCopy := make(map[string]models.Metrics)
+	m.dumpMutex.Lock()
+	defer m.dumpMutex.Unlock()
+
 	m.mutex.Lock()
-	for k, v := range m.Metrics {
-		metricCopy[k] = v
+	metrics := make([]models.Metrics, 0, len(m.Metrics))
+	for _, metric := range m.Metrics {
+		metrics = append(metrics, metric)
 	}
-	m.mutex.Unlock()
-
-	for _, metric := range metricCopy {
-		err := m.WriteMetric(&metric)
-		if err != nil {
+	// Значения в истории не меняются после записи, поэтому достаточно скопировать срезы.
+	history := make(map[string][]models.Sample, len(m.history))
+	for key, samples := range m.history {
+		history[key] = samples
+	}
+	generation := m.generation + 1
+	if m.wal != nil {
+		if err := m.wal.Rotate(generation); err != nil {
+			m.mutex.Unlock()
 			return err
 		}
 	}
-	return nil
+	m.generation = generation
+	m.mutex.Unlock()
+
+	if err := writeSnapshot(m.path, generation, metrics, history); err != nil {
+		return err
+	}
+	if m.wal == nil {
+		return nil
+	}
+	return m.wal.RemoveRotated(generation)
 }
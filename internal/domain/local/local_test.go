@@ -0,0 +1,59 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalRepositoryGetAllMetricsDuringUpdates(t *testing.T) {
+	repo := newTestRepository(t, t.TempDir(), false)
+	defer repo.Close()
+	ctx := context.Background()
+
+	// Чтение всех метрик, как при скрейпе /metrics, идет параллельно с обновлениями.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			_, err := repo.UpdateMetrics(ctx, gaugeBatch(fmt.Sprintf("Gauge%d", i), float64(i)))
+			assert.NoError(t, err)
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		_, err := repo.GetAllMetrics(ctx)
+		require.NoError(t, err)
+	}
+	wg.Wait()
+
+	metrics, err := repo.GetAllMetrics(ctx)
+	require.NoError(t, err)
+	assert.Len(t, metrics, 200)
+}
+
+func TestLocalRepositoryHistorySurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	t.Setenv("HISTORY_RETENTION", "1h")
+
+	repo := newTestRepository(t, dir, false)
+	_, err := repo.UpdateMetrics(ctx, gaugeBatch("Alloc", 1))
+	require.NoError(t, err)
+	_, err = repo.UpdateMetrics(ctx, gaugeBatch("Alloc", 2))
+	require.NoError(t, err)
+	require.NoError(t, repo.DumpMetrics())
+	require.NoError(t, repo.Close())
+
+	restored := newTestRepository(t, dir, true)
+	defer restored.Close()
+	samples, err := restored.GetMetricHistory(ctx, gaugeBatch("Alloc", 0)[0], time.Now().Add(-time.Minute), time.Now())
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, 2.0, samples[1].Value)
+}
@@ -0,0 +1,180 @@
+package local
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-svc-metrics/models"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// snapshotVersion версия формата снимка. Версия 2 добавила историю значений метрик.
+const snapshotVersion = 2
+
+// ErrSnapshotCorrupted снимок не прошел проверку контрольной суммы.
+var ErrSnapshotCorrupted = errors.New("snapshot is corrupted")
+
+// snapshotHeader первая строка снимка.
+// Снимок поколения Generation содержит все записи журналов меньших поколений.
+type snapshotHeader struct {
+	Version    int    `json:"version"`
+	Generation uint64 `json:"generation"`
+	Count      int    `json:"count"`
+	History    int    `json:"history,omitempty"`
+	Checksum   uint32 `json:"checksum"`
+}
+
+// historyRecord история значений одной метрики в снимке.
+type historyRecord struct {
+	Key     string          `json:"key"`
+	Samples []models.Sample `json:"samples"`
+}
+
+// writeSnapshot атомарно заменяет снимок: пишет временный файл, делает fsync и переименовывает.
+// Формат: строка заголовка, по строке JSON на метрику, затем по строке на историю метрики.
+// Checksum - CRC32 всех строк после заголовка.
+func writeSnapshot(path string, generation uint64, metrics []models.Metrics, history map[string][]models.Sample) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, metric := range metrics {
+		if err := encoder.Encode(metric); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]string, 0, len(history))
+	for key, samples := range history {
+		if len(samples) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := encoder.Encode(historyRecord{Key: key, Samples: history[key]}); err != nil {
+			return err
+		}
+	}
+
+	header, err := json.Marshal(snapshotHeader{
+		Version:    snapshotVersion,
+		Generation: generation,
+		Count:      len(metrics),
+		History:    len(keys),
+		Checksum:   crc32.ChecksumIEEE(body.Bytes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(append(header, '\n'))
+	if err == nil {
+		_, err = tmp.Write(body.Bytes())
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// readSnapshot читает снимок и историю значений метрик. Если снимка нет, возвращается пустой снимок поколения 0.
+// Файл в старом формате без заголовка читается построчно, последняя запись метрики побеждает.
+// В снимках версии 1 и старом формате истории нет.
+func readSnapshot(path string) (snapshotHeader, []models.Metrics, map[string][]models.Sample, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || len(data) == 0 {
+		return snapshotHeader{}, nil, nil, nil
+	}
+	if err != nil {
+		return snapshotHeader{}, nil, nil, err
+	}
+
+	firstLine, body, _ := bytes.Cut(data, []byte{'\n'})
+	var header snapshotHeader
+	if err = json.Unmarshal(firstLine, &header); err != nil || header.Version == 0 {
+		metrics, err := readLegacySnapshot(data)
+		return snapshotHeader{}, metrics, nil, err
+	}
+	if header.Version > snapshotVersion {
+		return header, nil, nil, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+	if crc32.ChecksumIEEE(body) != header.Checksum {
+		return header, nil, nil, ErrSnapshotCorrupted
+	}
+
+	metrics := make([]models.Metrics, 0, header.Count)
+	history := make(map[string][]models.Sample, header.History)
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	for len(metrics) < header.Count && decoder.More() {
+		var metric models.Metrics
+		if err = decoder.Decode(&metric); err != nil {
+			return header, nil, nil, err
+		}
+		metrics = append(metrics, metric)
+	}
+	for decoder.More() {
+		var record historyRecord
+		if err = decoder.Decode(&record); err != nil {
+			return header, nil, nil, err
+		}
+		history[record.Key] = record.Samples
+	}
+	if len(metrics) != header.Count || len(history) != header.History {
+		return header, nil, nil, ErrSnapshotCorrupted
+	}
+	return header, metrics, history, nil
+}
+
+// readLegacySnapshot читает дамп, который раньше дописывался в конец файла.
+func readLegacySnapshot(data []byte) ([]models.Metrics, error) {
+	latest := make(map[string]models.Metrics)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data))
+	for scanner.Scan() {
+		var metric models.Metrics
+		if err := json.Unmarshal(scanner.Bytes(), &metric); err != nil {
+			return nil, err
+		}
+		latest[metric.Key()] = metric
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	metrics := make([]models.Metrics, 0, len(latest))
+	for _, metric := range latest {
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+// syncDir сохраняет на диск запись каталога после переименования файла.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,155 @@
+package local
+
+import (
+	"context"
+	"go-svc-metrics/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metrics.dump")
+	delta := int64(3)
+	metrics := append(gaugeBatch("Alloc", 1.5), models.Metrics{ID: "PollCount", MType: models.Counter, Delta: &delta})
+	ts := time.Now()
+	history := map[string][]models.Sample{"Alloc": {{Timestamp: ts, Value: 1.5}}}
+
+	require.NoError(t, writeSnapshot(path, 1, metrics, nil))
+	require.NoError(t, writeSnapshot(path, 2, metrics, history))
+
+	header, restored, restoredHistory, err := readSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), header.Generation)
+	assert.ElementsMatch(t, metrics, restored)
+	require.Len(t, restoredHistory["Alloc"], 1)
+	assert.True(t, ts.Equal(restoredHistory["Alloc"][0].Timestamp))
+
+	// Временные файлы не остаются рядом со снимком.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	header, restored, _, err = readSnapshot(filepath.Join(dir, "missing.dump"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), header.Generation)
+	assert.Empty(t, restored)
+}
+
+func TestSnapshotCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.dump")
+	require.NoError(t, writeSnapshot(path, 1, gaugeBatch("Alloc", 1), nil))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	// Оборванный снимок.
+	require.NoError(t, os.WriteFile(path, data[:len(data)-3], 0666))
+
+	_, _, _, err = readSnapshot(path)
+	assert.ErrorIs(t, err, ErrSnapshotCorrupted)
+}
+
+func TestSnapshotLegacyFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.dump")
+	legacy := `{"id":"Alloc","type":"gauge","value":1}
+{"id":"Alloc","type":"gauge","value":2}
+`
+	require.NoError(t, os.WriteFile(path, []byte(legacy), 0666))
+
+	header, metrics, _, err := readSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), header.Generation)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, 2.0, *metrics[0].Value)
+}
+
+func TestLocalRepositoryRecoversAfterInterruptedDump(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := newTestRepository(t, dir, false)
+	_, err := repo.UpdateMetrics(ctx, gaugeBatch("Alloc", 1))
+	require.NoError(t, err)
+	require.NoError(t, repo.DumpMetrics())
+	_, err = repo.UpdateMetrics(ctx, gaugeBatch("Frees", 2))
+	require.NoError(t, err)
+	// Сбой после ротации журнала, но до записи снимка.
+	require.NoError(t, repo.wal.Rotate(repo.generation+1))
+	_, err = repo.UpdateMetrics(ctx, gaugeBatch("Alloc", 3))
+	require.NoError(t, err)
+	require.NoError(t, repo.wal.Close())
+
+	restored := newTestRepository(t, dir, true)
+	defer restored.Close()
+	assert.Equal(t, uint64(2), restored.generation)
+	metrics, err := restored.GetAllMetrics(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, append(gaugeBatch("Alloc", 3), gaugeBatch("Frees", 2)...), metrics)
+
+	// Следующий дамп получает новое поколение и удаляет ротированные журналы.
+	require.NoError(t, restored.DumpMetrics())
+	header, _, _, err := readSnapshot(restored.path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), header.Generation)
+	rotated, err := filepath.Glob(filepath.Join(dir, "metrics.wal.*"))
+	require.NoError(t, err)
+	assert.Empty(t, rotated)
+}
+
+func TestDumpMetricsByInterval(t *testing.T) {
+	dir := t.TempDir()
+	repo := newTestRepository(t, dir, false)
+	defer repo.Close()
+	repo.storeInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- repo.DumpMetricsByInterval(ctx) }()
+
+	_, err := repo.UpdateMetrics(context.Background(), gaugeBatch("Alloc", 1))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, metrics, _, err := readSnapshot(repo.path)
+		return err == nil && len(metrics) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	header, _, _, err := readSnapshot(repo.path)
+	require.NoError(t, err)
+	assert.Greater(t, header.Generation, uint64(1))
+}
+
+func TestSynchronousDump(t *testing.T) {
+	dir := t.TempDir()
+	repo := newTestRepository(t, dir, false)
+	defer repo.Close()
+	repo.storeInterval = 0
+
+	_, err := repo.UpdateMetrics(context.Background(), gaugeBatch("Alloc", 1))
+	require.NoError(t, err)
+	header, metrics, _, err := readSnapshot(repo.path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), header.Generation)
+	assert.Equal(t, gaugeBatch("Alloc", 1), metrics)
+}
+
+func TestSynchronousDumpFailureKeepsUpdate(t *testing.T) {
+	dir := t.TempDir()
+	repo := newTestRepository(t, dir, false)
+	defer repo.Close()
+	repo.storeInterval = 0
+	repo.path = filepath.Join(dir, "missing", "metrics.dump")
+
+	// Обновление уже в памяти и журнале, ошибка снимка не должна приводить к повтору запроса.
+	_, err := repo.UpdateMetrics(context.Background(), gaugeBatch("Alloc", 1))
+	require.NoError(t, err)
+	metric, err := repo.GetMetric(context.Background(), models.Metrics{ID: "Alloc", MType: models.Gauge})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, *metric.Value)
+}
@@ -0,0 +1,459 @@
+package local
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-svc-metrics/internal/logger"
+	"go-svc-metrics/models"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Режимы синхронизации WAL с диском.
+const (
+	// WALSyncAlways fsync после каждой записи до ответа клиенту.
+	WALSyncAlways = "always"
+	// WALSyncBatch запись ждет общего fsync вместе с параллельными записями.
+	WALSyncBatch = "batch"
+	// WALSyncInterval fsync по таймеру, при сбое теряются записи за последний интервал.
+	WALSyncInterval = "interval"
+)
+
+const (
+	walMagic      = "MWAL"
+	walVersion    = 1
+	walFileHeader = 16
+	walHeaderSize = 8
+	// walMaxRecordSize запись большего размера считается поврежденной.
+	walMaxRecordSize = 64 << 20
+)
+
+// WAL журнал обновлений хранилища.
+// Запись содержит состояние обновленных метрик после слияния, поэтому повторное
+// применение журнала поверх снимка восстанавливает последнее состояние каждой метрики.
+//
+// Файл начинается с заголовка: "MWAL", версия (uint32) и поколение (uint64).
+// При сохранении снимка журнал ротируется в файл path.<поколение>, новый журнал получает следующее поколение.
+// Формат записи: длина (uint32), CRC32 (uint32) и JSON массива метрик.
+type WAL struct {
+	path       string
+	mode       string
+	interval   time.Duration
+	generation uint64
+
+	// syncMutex не дает закрыть файл во время фонового fsync.
+	syncMutex sync.Mutex
+
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	file    *os.File
+	written uint64
+	synced  uint64
+	syncErr error
+
+	kick chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenWAL открывает журнал path для дозаписи.
+// Новый журнал получает поколение generation, у существующего поколение читается из заголовка.
+func OpenWAL(path, mode string, interval time.Duration, generation uint64) (*WAL, error) {
+	switch mode {
+	case WALSyncAlways, WALSyncBatch:
+	case WALSyncInterval:
+		if interval <= 0 {
+			return nil, fmt.Errorf("invalid wal sync interval %s", interval)
+		}
+	default:
+		return nil, fmt.Errorf("invalid wal sync mode %q", mode)
+	}
+
+	file, generation, err := openWALFile(path, generation)
+	if err != nil {
+		return nil, err
+	}
+
+	wal := &WAL{
+		path:       path,
+		mode:       mode,
+		interval:   interval,
+		generation: generation,
+		file:       file,
+		kick:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	wal.cond = sync.NewCond(&wal.mutex)
+
+	if mode == WALSyncAlways {
+		close(wal.done)
+	} else {
+		go wal.syncLoop()
+	}
+	return wal, nil
+}
+
+// Append дописывает запись и возвращает ее номер для Wait.
+// В режиме always запись синхронизируется с диском до возврата.
+func (w *WAL) Append(metrics []models.Metrics) (uint64, error) {
+	payload, err := json.Marshal(metrics)
+	if err != nil {
+		return 0, err
+	}
+	record := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[walHeaderSize:], payload)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.syncErr != nil {
+		return 0, w.syncErr
+	}
+	if _, err = w.file.Write(record); err != nil {
+		return 0, err
+	}
+	w.written++
+
+	switch w.mode {
+	case WALSyncAlways:
+		if err = w.file.Sync(); err != nil {
+			w.syncErr = err
+			return 0, err
+		}
+		w.synced = w.written
+	case WALSyncBatch:
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+	return w.written, nil
+}
+
+// Wait ждет, пока запись seq будет синхронизирована с диском.
+// В режиме interval не ждет.
+func (w *WAL) Wait(seq uint64) error {
+	if w.mode != WALSyncBatch {
+		return nil
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for w.synced < seq && w.syncErr == nil {
+		w.cond.Wait()
+	}
+	if w.synced >= seq {
+		return nil
+	}
+	return w.syncErr
+}
+
+// syncLoop синхронизирует журнал в режимах batch и interval.
+// Пока идет fsync, новые записи копятся и попадают в следующий fsync.
+func (w *WAL) syncLoop() {
+	defer close(w.done)
+
+	var tick <-chan time.Time
+	if w.mode == WALSyncInterval {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.kick:
+		case <-tick:
+		case <-w.stop:
+			return
+		}
+		w.sync()
+	}
+}
+
+func (w *WAL) sync() {
+	w.syncMutex.Lock()
+	defer w.syncMutex.Unlock()
+
+	w.mutex.Lock()
+	target, synced, file := w.written, w.synced, w.file
+	w.mutex.Unlock()
+	if target == synced {
+		return
+	}
+
+	err := file.Sync()
+
+	w.mutex.Lock()
+	if err != nil {
+		// После ошибки fsync нельзя полагаться на содержимое файла, журнал перестает принимать записи.
+		w.syncErr = err
+	} else if target > w.synced {
+		w.synced = target
+	}
+	w.cond.Broadcast()
+	w.mutex.Unlock()
+}
+
+// Generation возвращает поколение текущего журнала.
+func (w *WAL) Generation() uint64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.generation
+}
+
+// Replay применяет записи журналов поколения from и новее в порядке поколений.
+// Журналы меньших поколений уже входят в снимок и пропускаются.
+// Оборванная при сбое запись в конце текущего журнала отбрасывается.
+func (w *WAL) Replay(from uint64, apply func(metrics []models.Metrics)) error {
+	rotated, err := w.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for _, file := range rotated {
+		if file.generation < from {
+			continue
+		}
+		if _, err = replayWALFile(file.path, apply); err != nil {
+			return err
+		}
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	valid, err := replayWALFile(w.path, apply)
+	if err != nil {
+		return err
+	}
+	info, err := w.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > valid {
+		logger.Log.Warn("truncating torn wal tail", zap.String("path", w.path), zap.Int64("offset", valid))
+		return w.file.Truncate(valid)
+	}
+	return nil
+}
+
+// replayWALFile применяет записи файла и возвращает размер корректной части.
+func replayWALFile(path string, apply func(metrics []models.Metrics)) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if _, err = readWALFileHeader(reader); err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	header := make([]byte, walHeaderSize)
+	valid := int64(walFileHeader)
+	for {
+		if _, err = io.ReadFull(reader, header); err != nil {
+			return valid, nil
+		}
+		size := binary.LittleEndian.Uint32(header[0:4])
+		if size > walMaxRecordSize {
+			return valid, nil
+		}
+		payload := make([]byte, size)
+		if _, err = io.ReadFull(reader, payload); err != nil {
+			return valid, nil
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+			return valid, nil
+		}
+
+		var metrics []models.Metrics
+		if err = json.Unmarshal(payload, &metrics); err != nil {
+			return valid, nil
+		}
+		apply(metrics)
+		valid += int64(walHeaderSize + len(payload))
+	}
+}
+
+// Rotate начинает журнал поколения generation перед сохранением снимка.
+// Текущий журнал сохраняется в path.<поколение> до RemoveRotated.
+func (w *WAL) Rotate(generation uint64) error {
+	w.syncMutex.Lock()
+	defer w.syncMutex.Unlock()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.file.Sync(); err != nil {
+		w.syncErr = err
+		return err
+	}
+	w.synced = w.written
+	w.cond.Broadcast()
+
+	if err := os.Rename(w.path, rotatedWALPath(w.path, w.generation)); err != nil {
+		return err
+	}
+	file, generation, err := openWALFile(w.path, generation)
+	if err != nil {
+		return err
+	}
+	previous := w.file
+	w.file, w.generation = file, generation
+	return previous.Close()
+}
+
+// RemoveRotated удаляет журналы поколений меньше before, записи которых вошли в снимок.
+func (w *WAL) RemoveRotated(before uint64) error {
+	rotated, err := w.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for _, file := range rotated {
+		if file.generation >= before {
+			continue
+		}
+		if err = os.Remove(file.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset очищает журналы, если хранилище стартует без восстановления.
+// Новый журнал получает поколение generation.
+func (w *WAL) Reset(generation uint64) error {
+	if err := w.RemoveRotated(math.MaxUint64); err != nil {
+		return err
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if err := writeWALFileHeader(w.file, generation); err != nil {
+		return err
+	}
+	w.generation = generation
+	return w.file.Sync()
+}
+
+type rotatedWAL struct {
+	path       string
+	generation uint64
+}
+
+// rotatedFiles возвращает ротированные журналы в порядке поколений.
+func (w *WAL) rotatedFiles() ([]rotatedWAL, error) {
+	paths, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	rotated := make([]rotatedWAL, 0, len(paths))
+	for _, path := range paths {
+		generation, err := strconv.ParseUint(strings.TrimPrefix(path, w.path+"."), 10, 64)
+		if err != nil {
+			continue
+		}
+		rotated = append(rotated, rotatedWAL{path: path, generation: generation})
+	}
+	sort.Slice(rotated, func(i, j int) bool { return rotated[i].generation < rotated[j].generation })
+	return rotated, nil
+}
+
+func rotatedWALPath(path string, generation uint64) string {
+	return fmt.Sprintf("%s.%020d", path, generation)
+}
+
+// openWALFile открывает журнал для дозаписи. В пустой файл пишется заголовок поколения generation.
+func openWALFile(path string, generation uint64) (*os.File, uint64, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err == nil && info.Size() < walFileHeader {
+		// Пустой файл или заголовок, оборванный при создании.
+		if err = file.Truncate(0); err == nil {
+			err = writeWALFileHeader(file, generation)
+		}
+		if err == nil {
+			err = file.Sync()
+		}
+	} else if err == nil {
+		generation, err = readWALFileHeader(io.NewSectionReader(file, 0, walFileHeader))
+	}
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, generation, nil
+}
+
+func writeWALFileHeader(w io.Writer, generation uint64) error {
+	header := make([]byte, walFileHeader)
+	copy(header, walMagic)
+	binary.LittleEndian.PutUint32(header[4:8], walVersion)
+	binary.LittleEndian.PutUint64(header[8:16], generation)
+	_, err := w.Write(header)
+	return err
+}
+
+func readWALFileHeader(r io.Reader) (uint64, error) {
+	header := make([]byte, walFileHeader)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, fmt.Errorf("read wal header: %w", err)
+	}
+	if string(header[0:4]) != walMagic {
+		return 0, errors.New("invalid wal header")
+	}
+	if version := binary.LittleEndian.Uint32(header[4:8]); version != walVersion {
+		return 0, fmt.Errorf("unsupported wal version %d", version)
+	}
+	return binary.LittleEndian.Uint64(header[8:16]), nil
+}
+
+// Close синхронизирует и закрывает журнал.
+func (w *WAL) Close() error {
+	select {
+	case <-w.stop:
+		return nil
+	default:
+		close(w.stop)
+	}
+	<-w.done
+
+	w.syncMutex.Lock()
+	defer w.syncMutex.Unlock()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	err := w.file.Sync()
+	if err == nil {
+		w.synced = w.written
+	} else if w.syncErr == nil {
+		w.syncErr = err
+	}
+	w.cond.Broadcast()
+	return errors.Join(err, w.file.Close())
+}
@@ -0,0 +1,130 @@
+package local
+
+import (
+	"context"
+	"go-svc-metrics/internal/config"
+	"go-svc-metrics/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gaugeBatch(id string, value float64) []models.Metrics {
+	return []models.Metrics{{ID: id, MType: models.Gauge, Value: &value}}
+}
+
+func replayIDs(t *testing.T, wal *WAL) []string {
+	var ids []string
+	require.NoError(t, wal.Replay(0, func(metrics []models.Metrics) {
+		for _, metric := range metrics {
+			ids = append(ids, metric.ID)
+		}
+	}))
+	return ids
+}
+
+func TestWALSyncModes(t *testing.T) {
+	for _, mode := range []string{WALSyncAlways, WALSyncBatch, WALSyncInterval} {
+		t.Run(mode, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "metrics.wal")
+			wal, err := OpenWAL(path, mode, 10*time.Millisecond, 0)
+			require.NoError(t, err)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					seq, err := wal.Append(gaugeBatch("Alloc"+strconv.Itoa(i), 1))
+					assert.NoError(t, err)
+					assert.NoError(t, wal.Wait(seq))
+				}()
+			}
+			wg.Wait()
+			require.NoError(t, wal.Close())
+
+			wal, err = OpenWAL(path, mode, 10*time.Millisecond, 0)
+			require.NoError(t, err)
+			defer wal.Close()
+			assert.Len(t, replayIDs(t, wal), 10)
+		})
+	}
+
+	_, err := OpenWAL(filepath.Join(t.TempDir(), "metrics.wal"), "never", 0, 0)
+	assert.Error(t, err)
+}
+
+func TestWALTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.wal")
+	wal, err := OpenWAL(path, WALSyncAlways, 0, 0)
+	require.NoError(t, err)
+	_, err = wal.Append(gaugeBatch("Alloc", 1))
+	require.NoError(t, err)
+	require.NoError(t, wal.Close())
+
+	// Запись, оборванная при сбое.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0666)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{42, 0, 0, 0, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	wal, err = OpenWAL(path, WALSyncAlways, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alloc"}, replayIDs(t, wal))
+	_, err = wal.Append(gaugeBatch("Frees", 1))
+	require.NoError(t, err)
+	require.NoError(t, wal.Close())
+
+	wal, err = OpenWAL(path, WALSyncAlways, 0, 0)
+	require.NoError(t, err)
+	defer wal.Close()
+	assert.Equal(t, []string{"Alloc", "Frees"}, replayIDs(t, wal))
+}
+
+func newTestRepository(t *testing.T, dir string, restore bool) *MetricLocalRepository {
+	t.Setenv("FILE_STORAGE_PATH", filepath.Join(dir, "metrics.dump"))
+	t.Setenv("STORE_INTERVAL", "300s")
+	t.Setenv("RESTORE", strconv.FormatBool(restore))
+	t.Setenv("WAL_PATH", filepath.Join(dir, "metrics.wal"))
+	t.Setenv("WAL_SYNC", WALSyncBatch)
+	cfg, err := config.InitConfig()
+	require.NoError(t, err)
+
+	repo, err := NewMetricLocalRepository(cfg)
+	require.NoError(t, err)
+	return repo
+}
+
+func TestLocalRepositoryRecoversFromWAL(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	delta := int64(2)
+	counter := models.Metrics{ID: "PollCount", MType: models.Counter, Delta: &delta}
+
+	repo := newTestRepository(t, dir, false)
+	_, err := repo.UpdateMetrics(ctx, []models.Metrics{counter.Clone()})
+	require.NoError(t, err)
+	require.NoError(t, repo.DumpMetrics())
+	_, err = repo.UpdateMetrics(ctx, []models.Metrics{counter.Clone()})
+	require.NoError(t, err)
+	_, err = repo.UpdateMetrics(ctx, gaugeBatch("Alloc", 5))
+	require.NoError(t, err)
+	// Сбой: последний снимок не сохранен.
+	require.NoError(t, repo.wal.Close())
+
+	restored := newTestRepository(t, dir, true)
+	defer restored.Close()
+	metric, err := restored.GetMetric(ctx, models.Metrics{ID: "PollCount", MType: models.Counter})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), *metric.Delta)
+	metric, err = restored.GetMetric(ctx, models.Metrics{ID: "Alloc", MType: models.Gauge})
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, *metric.Value)
+}
@@ -2,12 +2,15 @@ package domain
 
 import (
 	"context"
+	"fmt"
 	"go-svc-metrics/internal/config"
 	"go-svc-metrics/internal/datasource"
+	"go-svc-metrics/internal/domain/kv"
 	"go-svc-metrics/internal/domain/local"
-	"go-svc-metrics/internal/domain/postgres"
+	"go-svc-metrics/internal/domain/sqlstore"
 	"go-svc-metrics/internal/logger"
 	"go-svc-metrics/models"
+	"time"
 )
 
 // MetricRepo интерфейс работы с репозиторием.
@@ -15,12 +18,52 @@ type MetricRepo interface {
 	UpdateMetrics(ctx context.Context, metrics []models.Metrics) ([]models.Metrics, error)
 	GetMetric(ctx context.Context, metric models.Metrics) (models.Metrics, error)
 	GetAllMetrics(ctx context.Context) ([]models.Metrics, error)
+	GetMetricHistory(ctx context.Context, metric models.Metrics, from, to time.Time) ([]models.Sample, error)
+	// ExpireHistory удаляет значения истории, которые старше now на период хранения.
+	ExpireHistory(ctx context.Context, now time.Time) error
 	Ping() error
 	Close() error
 	DumpMetricsByInterval(ctx context.Context) error
 }
 
+// Хранилища, которые выбираются параметром storage.
+const (
+	StorageFile     = "file"
+	StoragePostgres = "postgres"
+	StorageKV       = "kv"
+	StorageSQLite   = "sqlite"
+)
+
+// NewRepo создает хранилище, выбранное в конфиге.
+// Если хранилище не задано, используется postgres при доступной БД, иначе файл.
 func NewRepo(cfg *config.Config) (MetricRepo, error) {
+	storage := ""
+	if cfg.Storage != nil {
+		storage = *cfg.Storage
+	}
+
+	switch storage {
+	case StorageFile:
+		return local.NewMetricLocalRepository(cfg)
+	case StoragePostgres:
+		db, err := datasource.NewDatabase(*cfg.DatabaseDsn)
+		if err != nil {
+			return nil, err
+		}
+		return sqlstore.NewMetricRepository(db, sqlstore.Postgres, cfg.HistoryRetention.Duration), nil
+	case StorageKV:
+		return kv.NewMetricKVRepository(*cfg.KVPath, cfg.HistoryRetention.Duration)
+	case StorageSQLite:
+		db, err := datasource.NewSQLiteDatabase(*cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return sqlstore.NewMetricRepository(db, sqlstore.SQLite, cfg.HistoryRetention.Duration), nil
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown storage %q", storage)
+	}
+
 	var metricRepo MetricRepo
 	db, err := datasource.NewDatabase(*cfg.DatabaseDsn)
 	if err != nil {
@@ -35,7 +78,7 @@ func NewRepo(cfg *config.Config) (MetricRepo, error) {
 		}
 		metricRepo = localRepo
 	default:
-		postgresRepo := postgres.NewMetricRepository(db)
+		postgresRepo := sqlstore.NewMetricRepository(db, sqlstore.Postgres, cfg.HistoryRetention.Duration)
 		metricRepo = postgresRepo
 	}
 	return metricRepo, nil
@@ -8,6 +8,7 @@ import (
 	context "context"
 	models "go-svc-metrics/models"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -63,6 +64,20 @@ func (mr *MockMetricRepoMockRecorder) DumpMetricsByInterval(ctx interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DumpMetricsByInterval", reflect.TypeOf((*MockMetricRepo)(nil).DumpMetricsByInterval), ctx)
 }
 
+// ExpireHistory mocks base method.
+func (m *MockMetricRepo) ExpireHistory(ctx context.Context, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHistory", ctx, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExpireHistory indicates an expected call of ExpireHistory.
+func (mr *MockMetricRepoMockRecorder) ExpireHistory(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHistory", reflect.TypeOf((*MockMetricRepo)(nil).ExpireHistory), ctx, now)
+}
+
 // GetAllMetrics mocks base method.
 func (m *MockMetricRepo) GetAllMetrics(ctx context.Context) ([]models.Metrics, error) {
 	m.ctrl.T.Helper()
@@ -93,6 +108,21 @@ func (mr *MockMetricRepoMockRecorder) GetMetric(ctx, metric interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetric", reflect.TypeOf((*MockMetricRepo)(nil).GetMetric), ctx, metric)
 }
 
+// GetMetricHistory mocks base method.
+func (m *MockMetricRepo) GetMetricHistory(ctx context.Context, metric models.Metrics, from, to time.Time) ([]models.Sample, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetricHistory", ctx, metric, from, to)
+	ret0, _ := ret[0].([]models.Sample)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetricHistory indicates an expected call of GetMetricHistory.
+func (mr *MockMetricRepoMockRecorder) GetMetricHistory(ctx, metric, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetricHistory", reflect.TypeOf((*MockMetricRepo)(nil).GetMetricHistory), ctx, metric, from, to)
+}
+
 // Ping mocks base method.
 func (m *MockMetricRepo) Ping() error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,253 @@
+// Модуль sqlstore представляет реализацию интерфейса для работы с SQL базами: postgres и SQLite.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"time"
+)
+
+// Dialect различия SQL баз, которые нужны репозиторию.
+// Запросы пишутся с плейсхолдерами $1, $2, ..., rebind переводит их в синтаксис базы.
+type Dialect struct {
+	rebind func(query string) string
+	// lockMetric сериализует обновления одной метрики внутри транзакции. nil - база сама выполняет транзакции по одной.
+	lockMetric func(ctx context.Context, tx *sql.Tx, metric models.Metrics) error
+	// timestamp возвращает время в формате колонки ts.
+	timestamp    func(ts time.Time) any
+	storageError func(err error) error
+}
+
+type MetricRepository struct {
+	db               *sql.DB
+	dialect          Dialect
+	historyRetention time.Duration
+}
+
+func NewMetricRepository(db *sql.DB, dialect Dialect, historyRetention time.Duration) *MetricRepository {
+	return &MetricRepository{db: db, dialect: dialect, historyRetention: historyRetention}
+}
+
+func (m *MetricRepository) Ping() error {
+	return m.dialect.storageError(m.db.Ping())
+}
+
+func (m *MetricRepository) Close() error { return m.db.Close() }
+
+func (m *MetricRepository) DumpMetricsByInterval(_ context.Context) error {
+	return nil
+}
+
+func (m *MetricRepository) UpdateMetrics(ctx context.Context, metrics []models.Metrics) ([]models.Metrics, error) {
+	storageError := m.dialect.storageError
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return metrics, storageError(err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO metric_table as t1 (name_id, type, labels, delta, value, payload) VALUES ($1, $2, $3, $4, $5, $6)
+    ON CONFLICT (name_id, type, labels) DO UPDATE SET delta = t1.delta + EXCLUDED.delta, value = $5, payload = $6 RETURNING delta, value`
+	stmt, err := tx.PrepareContext(ctx, m.dialect.rebind(query))
+	if err != nil {
+		return metrics, storageError(err)
+	}
+	defer stmt.Close()
+
+	historyQuery := `INSERT INTO metric_history (name_id, type, labels, ts, value) VALUES ($1, $2, $3, $4, $5)`
+	historyStmt, err := tx.PrepareContext(ctx, m.dialect.rebind(historyQuery))
+	if err != nil {
+		return metrics, storageError(err)
+	}
+	defer historyStmt.Close()
+
+	now := time.Now()
+	for i, metric := range metrics {
+		var delta sql.NullInt64
+		var value sql.NullFloat64
+
+		if hasPayload(metric.MType) {
+			metric, err = m.mergePayload(ctx, tx, metric)
+			if err != nil {
+				return metrics, err
+			}
+			metrics[i] = metric
+		}
+		data, err := encodePayload(metric)
+		if err != nil {
+			return metrics, err
+		}
+
+		row := stmt.QueryRowContext(ctx, metric.ID, metric.MType, metric.Labels.Encode(), metric.Delta, metric.Value, data)
+		err = row.Scan(&delta, &value)
+		if err != nil {
+			return metrics, storageError(err)
+		}
+
+		if delta.Valid {
+			metrics[i].Delta = &delta.Int64
+		}
+		if value.Valid {
+			metrics[i].Value = &value.Float64
+		}
+		metric = metrics[i]
+
+		if m.historyRetention <= 0 {
+			continue
+		}
+		sample, ok := metric.SampleValue()
+		if !ok {
+			continue
+		}
+		_, err = historyStmt.ExecContext(ctx, metric.ID, metric.MType, metric.Labels.Encode(), m.dialect.timestamp(now), sample)
+		if err != nil {
+			return metrics, storageError(err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return metrics, storageError(err)
+	}
+	return metrics, nil
+}
+
+// ExpireHistory удаляет устаревшие значения истории одним запросом вне транзакций батчей.
+func (m *MetricRepository) ExpireHistory(ctx context.Context, now time.Time) error {
+	query := m.dialect.rebind(`DELETE FROM metric_history WHERE ts < $1`)
+	_, err := m.db.ExecContext(ctx, query, m.dialect.timestamp(now.Add(-m.historyRetention)))
+	return m.dialect.storageError(err)
+}
+
+// mergePayload сливает метрику с распределением с сохраненной в БД.
+func (m *MetricRepository) mergePayload(ctx context.Context, tx *sql.Tx, metric models.Metrics) (models.Metrics, error) {
+	if m.dialect.lockMetric != nil {
+		if err := m.dialect.lockMetric(ctx, tx, metric); err != nil {
+			return metric, m.dialect.storageError(err)
+		}
+	}
+
+	var data sql.NullString
+	query := m.dialect.rebind(`SELECT payload FROM metric_table WHERE name_id = $1 and type = $2 and labels = $3`)
+	row := tx.QueryRowContext(ctx, query, metric.ID, metric.MType, metric.Labels.Encode())
+	err := row.Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return metric, nil
+	}
+	if err != nil {
+		return metric, m.dialect.storageError(err)
+	}
+
+	stored := models.Metrics{ID: metric.ID, MType: metric.MType, Labels: metric.Labels}
+	if err = decodePayload(&stored, data); err != nil {
+		return metric, err
+	}
+	if err = stored.Merge(metric); err != nil {
+		return metric, err
+	}
+	return stored, nil
+}
+
+func (m *MetricRepository) GetMetric(ctx context.Context, metric models.Metrics) (models.Metrics, error) {
+	var delta sql.NullInt64
+	var value sql.NullFloat64
+	var data sql.NullString
+	query := m.dialect.rebind(`SELECT delta, value, payload FROM metric_table WHERE name_id = $1 and type = $2 and labels = $3`)
+	row := m.db.QueryRowContext(ctx, query, metric.ID, metric.MType, metric.Labels.Encode())
+	err := row.Scan(&delta, &value, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return metric, fmt.Errorf("%w: %w", errors2.ErrMetricNotFound, err)
+	}
+	if err != nil {
+		return metric, m.dialect.storageError(err)
+	}
+
+	if delta.Valid {
+		metric.Delta = &delta.Int64
+	}
+	if value.Valid {
+		metric.Value = &value.Float64
+	}
+	if err = decodePayload(&metric, data); err != nil {
+		return metric, err
+	}
+	return metric, nil
+}
+
+func (m *MetricRepository) GetAllMetrics(ctx context.Context) ([]models.Metrics, error) {
+	metrics := make([]models.Metrics, 0)
+	rows, err := m.db.QueryContext(ctx, "SELECT name_id, type, labels, delta, value, payload FROM metric_table")
+	if err != nil {
+		return metrics, m.dialect.storageError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delta sql.NullInt64
+		var value sql.NullFloat64
+		var data sql.NullString
+		var labels string
+		var metric models.Metrics
+		err := rows.Scan(&metric.ID, &metric.MType, &labels, &delta, &value, &data)
+		if err != nil {
+			return metrics, err
+		}
+		if err = decodePayload(&metric, data); err != nil {
+			return metrics, err
+		}
+		if delta.Valid {
+			metric.Delta = &delta.Int64
+		}
+		if value.Valid {
+			metric.Value = &value.Float64
+		}
+		metric.Labels, err = models.DecodeLabels(labels)
+		if err != nil {
+			return metrics, err
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, rows.Err()
+}
+
+func (m *MetricRepository) GetMetricHistory(ctx context.Context, metric models.Metrics, from, to time.Time) ([]models.Sample, error) {
+	samples := make([]models.Sample, 0)
+	query := m.dialect.rebind(`SELECT ts, value FROM metric_history WHERE name_id = $1 and type = $2 and labels = $3 and ts >= $4 and ts <= $5 ORDER BY ts`)
+	rows, err := m.db.QueryContext(ctx, query, metric.ID, metric.MType, metric.Labels.Encode(), m.dialect.timestamp(from), m.dialect.timestamp(to))
+	if err != nil {
+		return samples, m.dialect.storageError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts timestamp
+		var sample models.Sample
+		if err := rows.Scan(&ts, &sample.Value); err != nil {
+			return samples, err
+		}
+		sample.Timestamp = ts.Time
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
+
+// timestamp читает колонку ts: postgres возвращает время, SQLite - наносекунды Unix.
+type timestamp struct {
+	time.Time
+}
+
+func (t *timestamp) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		t.Time = v
+	case int64:
+		t.Time = time.Unix(0, v)
+	default:
+		return fmt.Errorf("unsupported timestamp %T", src)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package sqlstore
+
+import (
+	"context"
+	"go-svc-metrics/internal/datasource"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepository(t *testing.T, path string) *MetricRepository {
+	db, err := datasource.NewSQLiteDatabase(path)
+	require.NoError(t, err)
+	return NewMetricRepository(db, SQLite, time.Hour)
+}
+
+func histogram(counts []uint64, sum float64) models.Metrics {
+	var count uint64
+	for _, c := range counts {
+		count += c
+	}
+	return models.Metrics{ID: "Latency", MType: models.Histogram, Buckets: []float64{0.1, 1}, Counts: counts, Sum: &sum, Count: &count}
+}
+
+func TestSQLiteUpdateMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.sqlite")
+	ctx := context.Background()
+	delta, value := int64(2), 1.5
+
+	repo := newTestRepository(t, path)
+	updated, err := repo.UpdateMetrics(ctx, []models.Metrics{
+		{ID: "PollCount", MType: models.Counter, Delta: &delta},
+		{ID: "PollCount", MType: models.Counter, Delta: &delta, Labels: models.Labels{"host": "a"}},
+		{ID: "Alloc", MType: models.Gauge, Value: &value},
+		histogram([]uint64{1, 0, 0}, 0.05),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), *updated[0].Delta)
+
+	value = 2.5
+	updated, err = repo.UpdateMetrics(ctx, []models.Metrics{
+		{ID: "PollCount", MType: models.Counter, Delta: &delta},
+		{ID: "Alloc", MType: models.Gauge, Value: &value},
+		histogram([]uint64{0, 2, 1}, 3),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), *updated[0].Delta)
+	assert.Equal(t, 2.5, *updated[1].Value)
+	assert.Equal(t, []uint64{1, 2, 1}, updated[2].Counts)
+	require.NoError(t, repo.Close())
+
+	// Миграции не применяются повторно, данные сохраняются после переоткрытия.
+	repo = newTestRepository(t, path)
+	defer repo.Close()
+
+	metric, err := repo.GetMetric(ctx, models.Metrics{ID: "PollCount", MType: models.Counter})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), *metric.Delta)
+	metric, err = repo.GetMetric(ctx, models.Metrics{ID: "PollCount", MType: models.Counter, Labels: models.Labels{"host": "a"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), *metric.Delta)
+	metric, err = repo.GetMetric(ctx, models.Metrics{ID: "Latency", MType: models.Histogram})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), *metric.Count)
+
+	_, err = repo.GetMetric(ctx, models.Metrics{ID: "Frees", MType: models.Gauge})
+	assert.ErrorIs(t, err, errors2.ErrMetricNotFound)
+
+	metrics, err := repo.GetAllMetrics(ctx)
+	require.NoError(t, err)
+	assert.Len(t, metrics, 4)
+
+	samples, err := repo.GetMetricHistory(ctx, models.Metrics{ID: "Alloc", MType: models.Gauge}, time.Now().Add(-time.Minute), time.Now())
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, []float64{1.5, 2.5}, []float64{samples[0].Value, samples[1].Value})
+}
+
+func TestSQLiteUpdateMetricsRollback(t *testing.T) {
+	repo := newTestRepository(t, filepath.Join(t.TempDir(), "metrics.sqlite"))
+	defer repo.Close()
+	ctx := context.Background()
+	delta := int64(1)
+
+	_, err := repo.UpdateMetrics(ctx, []models.Metrics{histogram([]uint64{1, 0, 0}, 0.05)})
+	require.NoError(t, err)
+
+	// Несовместимая гистограмма откатывает весь батч.
+	mismatch := histogram([]uint64{1, 0}, 0.05)
+	mismatch.Buckets = []float64{0.5}
+	_, err = repo.UpdateMetrics(ctx, []models.Metrics{{ID: "PollCount", MType: models.Counter, Delta: &delta}, mismatch})
+	assert.ErrorIs(t, err, errors2.ErrBucketsMismatch)
+
+	_, err = repo.GetMetric(ctx, models.Metrics{ID: "PollCount", MType: models.Counter})
+	assert.ErrorIs(t, err, errors2.ErrMetricNotFound)
+}
+
+func TestRebindSQLite(t *testing.T) {
+	query := `INSERT INTO t (a, b) VALUES ($1, $2) ON CONFLICT (a) DO UPDATE SET b = $2, c = '$'`
+	assert.Equal(t, `INSERT INTO t (a, b) VALUES (?1, ?2) ON CONFLICT (a) DO UPDATE SET b = ?2, c = '$'`, rebindSQLite(query))
+}
+
+func TestSQLiteExpireHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t, filepath.Join(t.TempDir(), "metrics.sqlite"))
+	defer repo.Close()
+
+	value := 1.0
+	alloc := models.Metrics{ID: "Alloc", MType: models.Gauge, Value: &value}
+	_, err := repo.UpdateMetrics(ctx, []models.Metrics{alloc})
+	require.NoError(t, err)
+
+	now := time.Now()
+	require.NoError(t, repo.ExpireHistory(ctx, now))
+	samples, err := repo.GetMetricHistory(ctx, alloc, now.Add(-time.Minute), now)
+	require.NoError(t, err)
+	assert.Len(t, samples, 1)
+
+	require.NoError(t, repo.ExpireHistory(ctx, now.Add(2*time.Hour)))
+	samples, err = repo.GetMetricHistory(ctx, alloc, now.Add(-time.Minute), now)
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+}
@@ -0,0 +1,62 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"go-svc-metrics/internal/sketch"
+	"go-svc-metrics/models"
+)
+
+// payload состояние метрик с распределением, хранится в колонке payload в виде JSON.
+type payload struct {
+	Buckets []float64           `json:"buckets,omitempty"`
+	Counts  []uint64            `json:"counts,omitempty"`
+	Sum     *float64            `json:"sum,omitempty"`
+	Count   *uint64             `json:"count,omitempty"`
+	Sketch  *sketch.DDSketch    `json:"sketch,omitempty"`
+	HLL     *sketch.HyperLogLog `json:"hll,omitempty"`
+}
+
+// hasPayload проверяет, что состояние метрики хранится в payload.
+func hasPayload(mType string) bool {
+	return mType == models.Histogram || mType == models.Summary || mType == models.Set
+}
+
+// encodePayload возвращает payload метрики. Для метрик без payload возвращается NULL.
+func encodePayload(metric models.Metrics) (sql.NullString, error) {
+	if !hasPayload(metric.MType) {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(payload{
+		Buckets: metric.Buckets,
+		Counts:  metric.Counts,
+		Sum:     metric.Sum,
+		Count:   metric.Count,
+		Sketch:  metric.Sketch,
+		HLL:     metric.HLL,
+	})
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// decodePayload заполняет метрику из payload.
+func decodePayload(metric *models.Metrics, data sql.NullString) error {
+	if !data.Valid {
+		return nil
+	}
+
+	var p payload
+	if err := json.Unmarshal([]byte(data.String), &p); err != nil {
+		return err
+	}
+	metric.Buckets = p.Buckets
+	metric.Counts = p.Counts
+	metric.Sum = p.Sum
+	metric.Count = p.Count
+	metric.Sketch = p.Sketch
+	metric.HLL = p.HLL
+	return nil
+}
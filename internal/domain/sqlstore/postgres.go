@@ -0,0 +1,55 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"net"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Классы ошибок postgres, при которых БД недоступна:
+// 08 - ошибки соединения, 57 - вмешательство оператора (остановка сервера).
+const (
+	pqClassConnectionException  = "08"
+	pqClassOperatorIntervention = "57"
+)
+
+// Postgres диалект postgres. Плейсхолдеры $N передаются как есть.
+var Postgres = Dialect{
+	rebind:       func(query string) string { return query },
+	lockMetric:   lockPostgresMetric,
+	timestamp:    func(ts time.Time) any { return ts },
+	storageError: postgresStorageError,
+}
+
+// lockPostgresMetric блокирует метрику до конца транзакции,
+// чтобы параллельные батчи не потеряли обновления payload.
+func lockPostgresMetric(ctx context.Context, tx *sql.Tx, metric models.Metrics) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1 || $2 || $3))`, metric.ID, metric.MType, metric.Labels.Encode())
+	return err
+}
+
+// postgresStorageError помечает ошибки соединения с БД как ErrStorageUnavailable.
+func postgresStorageError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var netErr net.Error
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone), errors.As(err, &netErr):
+		return fmt.Errorf("%w: %w", errors2.ErrStorageUnavailable, err)
+	case errors.As(err, &pqErr) &&
+		(pqErr.Code.Class() == pqClassConnectionException || pqErr.Code.Class() == pqClassOperatorIntervention):
+		return fmt.Errorf("%w: %w", errors2.ErrStorageUnavailable, err)
+	}
+	return err
+}
@@ -0,0 +1,37 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"time"
+)
+
+// SQLite диалект SQLite. Транзакции выполняются по одной, поэтому блокировка метрики не нужна.
+// Время в колонке ts хранится в наносекундах Unix.
+var SQLite = Dialect{
+	rebind:       rebindSQLite,
+	timestamp:    func(ts time.Time) any { return ts.UnixNano() },
+	storageError: sqliteStorageError,
+}
+
+// rebindSQLite заменяет плейсхолдеры $N на ?N.
+func rebindSQLite(query string) string {
+	data := []byte(query)
+	for i := 0; i+1 < len(data); i++ {
+		if data[i] == '$' && data[i+1] >= '0' && data[i+1] <= '9' {
+			data[i] = '?'
+		}
+	}
+	return string(data)
+}
+
+// sqliteStorageError помечает ошибки соединения с БД как ErrStorageUnavailable.
+func sqliteStorageError(err error) error {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return fmt.Errorf("%w: %w", errors2.ErrStorageUnavailable, err)
+	}
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"go-svc-metrics/internal/domain/mocks"
 	"go-svc-metrics/internal/router"
 	"go-svc-metrics/internal/service"
+	"go-svc-metrics/internal/sketch"
 	"go-svc-metrics/internal/utils/helpers"
 	"net/http"
 	"net/http/httptest"
@@ -54,3 +55,118 @@ func TestAllMetricsHandler(t *testing.T) {
 		})
 	}
 }
+
+var (
+	histogramSum   = 0.52
+	histogramCount = uint64(4)
+
+	histogramMetric = models.Metrics{
+		ID:      "Latency",
+		MType:   models.Histogram,
+		Buckets: []float64{0.1, 0.5},
+		Counts:  []uint64{3, 1, 0},
+		Sum:     &histogramSum,
+		Count:   &histogramCount,
+		Labels:  models.Labels{"path": "/"},
+	}
+)
+
+func summaryMetric() models.Metrics {
+	pauses, _ := sketch.New(sketch.DefaultRelativeAccuracy)
+	for i := 0; i < 3; i++ {
+		pauses.Add(1)
+	}
+	return models.Metrics{ID: "GCPause", MType: models.Summary, Sketch: pauses}
+}
+
+func TestPrometheusMetricsHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics []models.Metrics
+		code    int
+		body    string
+	}{
+		{
+			name:    "positive test prometheus handler",
+			metrics: []models.Metrics{helpers.GaugeMetric, helpers.CounterMetric},
+			code:    http.StatusOK,
+			body:    "# TYPE CounterMetric counter\nCounterMetric 4\n# TYPE GaugeMetric gauge\nGaugeMetric 1\n",
+		},
+		{
+			name: "positive test prometheus handler with name sanitisation",
+			metrics: []models.Metrics{
+				{ID: "1cpu.usage-%", MType: models.Gauge, Value: &helpers.ValidGaugeValue},
+			},
+			code: http.StatusOK,
+			body: "# TYPE _1cpu_usage__ gauge\n_1cpu_usage__ 1\n",
+		},
+		{
+			name: "positive test prometheus handler with labels",
+			metrics: []models.Metrics{
+				{ID: "CPUutilization", MType: models.Gauge, Value: &helpers.ValidGaugeValue, Labels: models.Labels{"cpu": "2"}},
+				{ID: "CPUutilization", MType: models.Gauge, Value: &helpers.ValidGaugeValue, Labels: models.Labels{"cpu": "1"}},
+			},
+			code: http.StatusOK,
+			body: "# TYPE CPUutilization gauge\nCPUutilization{cpu=\"1\"} 1\nCPUutilization{cpu=\"2\"} 1\n",
+		},
+		{
+			name:    "positive test prometheus handler with histogram",
+			metrics: []models.Metrics{histogramMetric},
+			code:    http.StatusOK,
+			body: "# TYPE Latency histogram\n" +
+				"Latency_bucket{le=\"0.1\",path=\"/\"} 3\n" +
+				"Latency_bucket{le=\"0.5\",path=\"/\"} 4\n" +
+				"Latency_bucket{le=\"+Inf\",path=\"/\"} 4\n" +
+				"Latency_sum{path=\"/\"} 0.52\n" +
+				"Latency_count{path=\"/\"} 4\n",
+		},
+		{
+			name:    "positive test prometheus handler with summary",
+			metrics: []models.Metrics{summaryMetric()},
+			code:    http.StatusOK,
+			body: "# TYPE GCPause summary\n" +
+				"GCPause{quantile=\"0.5\"} 1\n" +
+				"GCPause{quantile=\"0.9\"} 1\n" +
+				"GCPause{quantile=\"0.99\"} 1\n" +
+				"GCPause_sum 3\n" +
+				"GCPause_count 3\n",
+		},
+		{
+			name: "positive test prometheus handler with name collisions",
+			metrics: []models.Metrics{
+				{ID: "Alloc", MType: models.Gauge, Value: &helpers.ValidGaugeValue},
+				{ID: "Alloc", MType: models.Counter, Delta: &helpers.ValidCounterValue},
+				{ID: "cpu.usage", MType: models.Gauge, Value: &helpers.ValidGaugeValue},
+				{ID: "cpu_usage", MType: models.Gauge, Value: &helpers.ValidGaugeValue},
+				{ID: "Latency_count", MType: models.Gauge, Value: &helpers.ValidGaugeValue},
+				histogramMetric,
+			},
+			code: http.StatusOK,
+			body: "# TYPE Alloc counter\nAlloc 4\n" +
+				"# TYPE Latency histogram\n" +
+				"Latency_bucket{le=\"0.1\",path=\"/\"} 3\n" +
+				"Latency_bucket{le=\"0.5\",path=\"/\"} 4\n" +
+				"Latency_bucket{le=\"+Inf\",path=\"/\"} 4\n" +
+				"Latency_sum{path=\"/\"} 0.52\n" +
+				"Latency_count{path=\"/\"} 4\n" +
+				"# TYPE cpu_usage gauge\ncpu_usage 1\n",
+		},
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetricRepo := mocks.NewMockMetricRepo(ctrl)
+	ts := NewTestServer(mockMetricRepo)
+	defer ts.Close()
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			mockMetricRepo.EXPECT().GetAllMetrics(gomock.Any()).Return(v.metrics, nil)
+
+			resp, body := helpers.TestRequest(t, ts, http.MethodGet, "/metrics", []byte{})
+			defer resp.Body.Close()
+			assert.Equal(t, v.code, resp.StatusCode)
+			assert.Equal(t, v.body, body)
+		})
+	}
+}
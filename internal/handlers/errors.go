@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"net/http"
+)
+
+// errorStatusCode возвращает HTTP код ответа для ошибки сервиса.
+func errorStatusCode(err error) int {
+	switch {
+	case errors2.IsValidation(err):
+		return http.StatusBadRequest
+	case errors.Is(err, errors2.ErrMetricNotFound), errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, errors2.ErrStorageUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
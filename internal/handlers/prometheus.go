@@ -0,0 +1,302 @@
+// модуль handlers релизуют хендлеры сервера по сбору метрик.
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"go-svc-metrics/internal/logger"
+	"go-svc-metrics/models"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PrometheusContentType тип контента text exposition format 0.0.4.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// GetPrometheusMetrics обработка ендпоинта GET /metrics .
+// Возвращает все метрики в текстовом формате Prometheus.
+//
+// Example:
+//
+//	http://localhost:8080/metrics
+//
+// Output:
+//
+//	# TYPE CounterMetric counter
+//	CounterMetric 4
+//	# TYPE GaugeMetric gauge
+//	GaugeMetric 1
+//	# TYPE Latency histogram
+//	Latency_bucket{le="0.1"} 3
+//	Latency_bucket{le="+Inf"} 4
+//	Latency_sum 0.52
+//	Latency_count 4
+func (m *CommonHandlers) GetPrometheusMetrics(res http.ResponseWriter, req *http.Request) {
+	metrics, err := m.metricService.GetAllMetrics(req.Context())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", PrometheusContentType)
+	res.WriteHeader(http.StatusOK)
+	// Заголовок уже отправлен, поэтому ошибку записи можно только залогировать.
+	if err := writePrometheusMetrics(res, metrics); err != nil {
+		logger.Log.Warn("cannot write prometheus metrics", zap.Error(err))
+	}
+}
+
+// prometheusFamily метрики с одним именем и типом Prometheus.
+type prometheusFamily struct {
+	name    string
+	mType   string
+	metrics []models.Metrics
+}
+
+// writePrometheusMetrics пишет метрики в формате Prometheus, группируя их по имени и типу.
+func writePrometheusMetrics(w io.Writer, metrics []models.Metrics) error {
+	bw := bufio.NewWriter(w)
+	for _, family := range groupPrometheusFamilies(metrics) {
+		fmt.Fprintf(bw, "# TYPE %s %s\n", family.name, family.mType)
+		for _, metric := range family.metrics {
+			labels := formatPrometheusLabels(metric.Labels)
+
+			switch metric.MType {
+			case models.Counter:
+				if metric.Delta != nil {
+					fmt.Fprintf(bw, "%s%s %d\n", family.name, labels, *metric.Delta)
+				}
+			case models.Gauge:
+				if metric.Value != nil {
+					fmt.Fprintf(bw, "%s%s %s\n", family.name, labels, formatPrometheusFloat(*metric.Value))
+				}
+			case models.Histogram:
+				writePrometheusHistogram(bw, family.name, metric)
+			case models.Summary:
+				writePrometheusSummary(bw, family.name, metric)
+			case models.Set:
+				fmt.Fprintf(bw, "%s%s %d\n", family.name, labels, metric.Cardinality())
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// groupPrometheusFamilies группирует метрики по приведенному имени и типу Prometheus.
+// Разные ID могут дать одно имя, а один ID может быть и counter, и gauge. В выводе у имени
+// может быть только один тип и одна серия на набор меток, поэтому конфликтующие метрики
+// пропускаются: остается первая в порядке сортировки.
+func groupPrometheusFamilies(metrics []models.Metrics) []*prometheusFamily {
+	type sortedMetric struct {
+		metric models.Metrics
+		name   string
+		mType  string
+		labels string
+	}
+
+	sorted := make([]sortedMetric, 0, len(metrics))
+	for _, metric := range metrics {
+		if models.IsValidType(metric.MType) {
+			sorted = append(sorted, sortedMetric{
+				metric: metric,
+				name:   sanitizeMetricName(metric.ID),
+				mType:  prometheusType(metric.MType),
+				labels: formatPrometheusLabels(metric.Labels),
+			})
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if a.mType != b.mType {
+			return a.mType < b.mType
+		}
+		if a.labels != b.labels {
+			return a.labels < b.labels
+		}
+		return a.metric.ID < b.metric.ID
+	})
+
+	families := make([]*prometheusFamily, 0)
+	// claimed имена серий, занятые семействами, например Latency_sum у histogram Latency.
+	claimed := make(map[string]bool)
+	var family *prometheusFamily
+	var lastLabels string
+	for _, v := range sorted {
+		if family != nil && family.name == v.name && family.mType == v.mType {
+			if v.labels == lastLabels {
+				skipPrometheusMetric(v.metric, v.name, "duplicate series")
+				continue
+			}
+			family.metrics = append(family.metrics, v.metric)
+			lastLabels = v.labels
+			continue
+		}
+
+		seriesNames := prometheusSeriesNames(v.name, v.mType)
+		if conflictsWithClaimed(claimed, seriesNames) {
+			skipPrometheusMetric(v.metric, v.name, "name is used by another metric type")
+			continue
+		}
+
+		family = &prometheusFamily{name: v.name, mType: v.mType, metrics: []models.Metrics{v.metric}}
+		lastLabels = v.labels
+		families = append(families, family)
+		for _, name := range seriesNames {
+			claimed[name] = true
+		}
+	}
+	return families
+}
+
+// prometheusSeriesNames возвращает имена серий, которые пишет семейство.
+func prometheusSeriesNames(name, mType string) []string {
+	switch mType {
+	case models.Histogram:
+		return []string{name, name + "_bucket", name + "_sum", name + "_count"}
+	case models.Summary:
+		return []string{name, name + "_sum", name + "_count"}
+	}
+	return []string{name}
+}
+
+func conflictsWithClaimed(claimed map[string]bool, names []string) bool {
+	for _, name := range names {
+		if claimed[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func skipPrometheusMetric(metric models.Metrics, name, reason string) {
+	logger.Log.Warn("skipping metric in prometheus output",
+		zap.String("id", metric.ID), zap.String("type", metric.MType), zap.String("name", name), zap.String("reason", reason))
+}
+
+// writePrometheusHistogram пишет накопленные корзины name_bucket{le="..."}, name_sum и name_count.
+func writePrometheusHistogram(w io.Writer, name string, metric models.Metrics) {
+	if metric.Sum == nil || metric.Count == nil || len(metric.Counts) != len(metric.Buckets)+1 {
+		return
+	}
+
+	bucketLabels := make(models.Labels, len(metric.Labels)+1)
+	for k, v := range metric.Labels {
+		bucketLabels[k] = v
+	}
+	for i, count := range metric.CumulativeCounts() {
+		bucketLabels["le"] = "+Inf"
+		if i < len(metric.Buckets) {
+			bucketLabels["le"] = formatPrometheusFloat(metric.Buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, formatPrometheusLabels(bucketLabels), count)
+	}
+
+	labels := formatPrometheusLabels(metric.Labels)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatPrometheusFloat(*metric.Sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, *metric.Count)
+}
+
+// prometheusType возвращает тип метрики в формате Prometheus.
+// Для set выводится оценка количества уникальных значений, поэтому это gauge.
+func prometheusType(mType string) string {
+	if mType == models.Set {
+		return models.Gauge
+	}
+	return mType
+}
+
+// prometheusSummaryQuantiles квантили summary в выводе /metrics.
+var prometheusSummaryQuantiles = []float64{0.5, 0.9, 0.99}
+
+// writePrometheusSummary пишет квантили name{quantile="..."}, name_sum и name_count.
+func writePrometheusSummary(w io.Writer, name string, metric models.Metrics) {
+	if metric.Sketch == nil {
+		return
+	}
+
+	quantileLabels := make(models.Labels, len(metric.Labels)+1)
+	for k, v := range metric.Labels {
+		quantileLabels[k] = v
+	}
+	for _, q := range prometheusSummaryQuantiles {
+		value, err := metric.Sketch.Quantile(q)
+		if err != nil {
+			continue
+		}
+		quantileLabels["quantile"] = formatPrometheusFloat(q)
+		fmt.Fprintf(w, "%s%s %s\n", name, formatPrometheusLabels(quantileLabels), formatPrometheusFloat(value))
+	}
+
+	labels := formatPrometheusLabels(metric.Labels)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatPrometheusFloat(metric.Sketch.Sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, metric.Sketch.Count())
+}
+
+// sanitizeMetricName приводит имя метрики к виду [a-zA-Z_:][a-zA-Z0-9_:]*.
+func sanitizeMetricName(name string) string {
+	if name == "" {
+		return "_"
+	}
+
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// formatPrometheusLabels возвращает метки в виде {a="1",b="2"}.
+func formatPrometheusLabels(labels models.Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(sanitizeLabelName(name))
+		b.WriteString(`="`)
+		b.WriteString(labelValueReplacer.Replace(labels[name]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// sanitizeLabelName приводит имя метки к виду [a-zA-Z_][a-zA-Z0-9_]*.
+func sanitizeLabelName(name string) string {
+	return strings.ReplaceAll(sanitizeMetricName(name), ":", "_")
+}
+
+func formatPrometheusFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,154 @@
+// модуль handlers релизуют хендлеры сервера по сбору метрик.
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-svc-metrics/internal/service"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryHandlers хранит слой сервиса.
+type QueryHandlers struct {
+	metricService *service.MetricService
+}
+
+// NewQueryHandlers создает и возвращает новый QueryHandlers.
+func NewQueryHandlers(metricService *service.MetricService) *QueryHandlers {
+	return &QueryHandlers{metricService: metricService}
+}
+
+type queryRangeResponse struct {
+	ID          string          `json:"id"`
+	MType       string          `json:"type"`
+	Labels      models.Labels   `json:"labels,omitempty"`
+	Aggregation string          `json:"aggregation,omitempty"`
+	Step        string          `json:"step"`
+	Values      []models.Sample `json:"values"`
+}
+
+// QueryRange обработка ендпоинта GET /api/v1/query_range .
+// Возвращает значения метрики за период, агрегированные по интервалам step.
+// start и end задаются в RFC3339 или unix-секундах, step - длительностью (1m) или секундами.
+// agg: avg, min, max, last, sum для gauge и rate, increase для counter.
+// Метки передаются параметром label=name=value.
+//
+// Example:
+//
+//	http://localhost:8080/api/v1/query_range?id=FreeMemory&type=gauge&start=2025-10-01T10:00:00Z&end=2025-10-01T10:05:00Z&step=1m&agg=max
+//
+// Output:
+//
+//	{
+//	    "id": "FreeMemory",
+//	    "type": "gauge",
+//	    "aggregation": "max",
+//	    "step": "1m0s",
+//	    "values": [
+//	        {"timestamp": "2025-10-01T10:00:00Z", "value": 1024}
+//	    ]
+//	}
+func (m *QueryHandlers) QueryRange(res http.ResponseWriter, req *http.Request) {
+	query, err := parseRangeQuery(req.URL.Query())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	samples, err := m.metricService.QueryRange(req.Context(), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, errors2.ErrInvalidQueryRange),
+			errors.Is(err, errors2.ErrInvalidAggregation),
+			errors.Is(err, errors2.ErrInvalidMetricVType):
+			http.Error(res, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(res, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	jsonData, err := json.Marshal(queryRangeResponse{
+		ID:          query.Metric.ID,
+		MType:       query.Metric.MType,
+		Labels:      query.Metric.Labels,
+		Aggregation: query.Aggregation,
+		Step:        query.Step.String(),
+		Values:      samples,
+	})
+	if err != nil {
+		http.Error(res, "invalid marshaling", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	res.Write(jsonData)
+}
+
+func parseRangeQuery(values url.Values) (service.RangeQuery, error) {
+	var query service.RangeQuery
+	var err error
+
+	query.Metric = models.Metrics{ID: values.Get("id"), MType: values.Get("type")}
+	if query.Metric.ID == "" {
+		return query, fmt.Errorf("%w: id is required", errors2.ErrInvalidQueryRange)
+	}
+
+	if query.Metric.Labels, err = parseLabels(values); err != nil {
+		return query, fmt.Errorf("%w: %w", errors2.ErrInvalidQueryRange, err)
+	}
+
+	if query.Start, err = parseQueryTime(values.Get("start")); err != nil {
+		return query, fmt.Errorf("%w: start: %v", errors2.ErrInvalidQueryRange, err)
+	}
+	if query.End, err = parseQueryTime(values.Get("end")); err != nil {
+		return query, fmt.Errorf("%w: end: %v", errors2.ErrInvalidQueryRange, err)
+	}
+	if query.Step, err = parseQueryStep(values.Get("step")); err != nil {
+		return query, fmt.Errorf("%w: step: %v", errors2.ErrInvalidQueryRange, err)
+	}
+	query.Aggregation = values.Get("agg")
+	if query.Aggregation == "" {
+		query.Aggregation = service.DefaultAggregation(query.Metric.MType)
+	}
+	return query, nil
+}
+
+func parseQueryTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		whole, frac := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(frac*float64(time.Second))), nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func parseQueryStep(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(value)
+}
+
+// parseLabels читает метки из параметров label=name=value.
+func parseLabels(values url.Values) (models.Labels, error) {
+	var labels models.Labels
+	for _, label := range values["label"] {
+		name, value, ok := strings.Cut(label, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid label %q", label)
+		}
+		if labels == nil {
+			labels = make(models.Labels)
+		}
+		labels[name] = value
+	}
+	return labels, nil
+}
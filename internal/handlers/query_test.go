@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"go-svc-metrics/internal/domain/mocks"
+	"go-svc-metrics/internal/utils/helpers"
+	"go-svc-metrics/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryRangeHandler(t *testing.T) {
+	start := time.Date(2025, 10, 1, 10, 0, 0, 0, time.UTC)
+	gaugeSamples := []models.Sample{
+		{Timestamp: start.Add(10 * time.Second), Value: 1},
+		{Timestamp: start.Add(20 * time.Second), Value: 3},
+		{Timestamp: start.Add(70 * time.Second), Value: 5},
+		// Точка в конце периода не попадает в результат.
+		{Timestamp: start.Add(2 * time.Minute), Value: 7},
+	}
+	counterSamples := []models.Sample{
+		{Timestamp: start.Add(-10 * time.Second), Value: 10},
+		{Timestamp: start.Add(30 * time.Second), Value: 40},
+		{Timestamp: start.Add(90 * time.Second), Value: 100},
+		{Timestamp: start.Add(100 * time.Second), Value: 20},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		code       int
+		values     []models.Sample
+		mockExpect func(mockRepo *mocks.MockMetricRepo)
+	}{
+		{
+			name: "positive test query range avg for gauge #1",
+			path: "/api/v1/query_range?id=GaugeMetric&type=gauge&start=2025-10-01T10:00:00Z&end=2025-10-01T10:02:00Z&step=1m",
+			code: http.StatusOK,
+			values: []models.Sample{
+				{Timestamp: start, Value: 2},
+				{Timestamp: start.Add(time.Minute), Value: 5},
+			},
+			mockExpect: func(mockRepo *mocks.MockMetricRepo) {
+				mockRepo.EXPECT().GetMetricHistory(gomock.Any(), helpers.GaugeMetricRequest, gomock.Any(), gomock.Any()).Return(gaugeSamples, nil)
+			},
+		},
+		{
+			name: "positive test query range max for gauge #2",
+			path: "/api/v1/query_range?id=GaugeMetric&type=gauge&start=1759312800&end=1759312920&step=60&agg=max",
+			code: http.StatusOK,
+			values: []models.Sample{
+				{Timestamp: start, Value: 3},
+				{Timestamp: start.Add(time.Minute), Value: 5},
+			},
+			mockExpect: func(mockRepo *mocks.MockMetricRepo) {
+				mockRepo.EXPECT().GetMetricHistory(gomock.Any(), helpers.GaugeMetricRequest, gomock.Any(), gomock.Any()).Return(gaugeSamples, nil)
+			},
+		},
+		{
+			name: "positive test query range increase for counter with reset #3",
+			path: "/api/v1/query_range?id=CounterMetric&type=counter&start=2025-10-01T10:00:00Z&end=2025-10-01T10:02:00Z&step=1m&agg=increase",
+			code: http.StatusOK,
+			values: []models.Sample{
+				{Timestamp: start, Value: 30},
+				{Timestamp: start.Add(time.Minute), Value: 80},
+			},
+			mockExpect: func(mockRepo *mocks.MockMetricRepo) {
+				mockRepo.EXPECT().GetMetricHistory(gomock.Any(), helpers.CounterMetricRequest, gomock.Any(), gomock.Any()).Return(counterSamples, nil)
+			},
+		},
+		{
+			name:       "Negative test query range - invalid aggregation for counter #4",
+			path:       "/api/v1/query_range?id=CounterMetric&type=counter&start=2025-10-01T10:00:00Z&end=2025-10-01T10:02:00Z&step=1m&agg=avg",
+			code:       http.StatusBadRequest,
+			mockExpect: func(mockRepo *mocks.MockMetricRepo) {},
+		},
+		{
+			name:       "Negative test query range - end before start #5",
+			path:       "/api/v1/query_range?id=GaugeMetric&type=gauge&start=2025-10-01T10:02:00Z&end=2025-10-01T10:00:00Z&step=1m",
+			code:       http.StatusBadRequest,
+			mockExpect: func(mockRepo *mocks.MockMetricRepo) {},
+		},
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetricRepo := mocks.NewMockMetricRepo(ctrl)
+	ts := NewTestServer(mockMetricRepo)
+	defer ts.Close()
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			v.mockExpect(mockMetricRepo)
+			resp, body := helpers.TestRequest(t, ts, http.MethodGet, v.path, []byte{})
+			defer resp.Body.Close()
+			assert.Equal(t, v.code, resp.StatusCode)
+			if v.code != http.StatusOK {
+				return
+			}
+
+			var result struct {
+				Values []models.Sample `json:"values"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(body), &result))
+			require.Len(t, result.Values, len(v.values))
+			for i, value := range v.values {
+				assert.True(t, value.Timestamp.Equal(result.Values[i].Timestamp))
+				assert.InDelta(t, value.Value, result.Values[i].Value, 1e-9)
+			}
+		})
+	}
+}
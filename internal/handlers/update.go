@@ -3,11 +3,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"go-svc-metrics/internal/service"
 	errors2 "go-svc-metrics/internal/utils/errors"
 	"go-svc-metrics/models"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -67,7 +69,7 @@ func (m *UpdateHandlers) V2UpdateMetric(res http.ResponseWriter, req *http.Reque
 
 	updatedMetrics, err := m.metricService.UpdateMetrics(req.Context(), []models.Metrics{metric})
 	if err != nil {
-		http.Error(res, errors2.ErrInvalidCounterOperation.Error(), http.StatusInternalServerError)
+		http.Error(res, err.Error(), errorStatusCode(err))
 		return
 	}
 
@@ -81,8 +83,22 @@ func (m *UpdateHandlers) V2UpdateMetric(res http.ResponseWriter, req *http.Reque
 	res.Write(jsonData)
 }
 
+// batchItemError ошибка метрики батча по ее индексу.
+type batchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// batchUpdateResponse ответ на батч с ошибками.
+type batchUpdateResponse struct {
+	Metrics models.BatchMetrics `json:"metrics,omitempty"`
+	Errors  []batchItemError    `json:"errors,omitempty"`
+}
+
 // UpdateBatchMetrics обработка ендпоинта POST /updates/ .
 // Возвращает значение метрики.
+// По умолчанию батч применяется целиком: при ошибке в любой метрике ответ 400 с ошибками по индексам.
+// С atomic=false применяются только корректные метрики, ответ содержит обновленные метрики и ошибки остальных.
 //
 // Example:
 //
@@ -117,9 +133,30 @@ func (m *UpdateHandlers) V2UpdateMetric(res http.ResponseWriter, req *http.Reque
 //	    "delta": "4"
 //	  }
 //	]
+//
+// Example:
+//
+//	http://localhost:8080/updates/?atomic=false
+//
+// Output:
+//
+//	{
+//	    "metrics": [{"id": "Alloc", "type": "gauge", "value": 1024}],
+//	    "errors": [{"index": 1, "error": "metrics[1].delta: missing metric value"}]
+//	}
 func (m *UpdateHandlers) UpdateBatchMetrics(res http.ResponseWriter, req *http.Request) {
 	var metrics []models.Metrics
 
+	atomic := true
+	if value := req.URL.Query().Get("atomic"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(res, "invalid atomic parameter", http.StatusBadRequest)
+			return
+		}
+		atomic = parsed
+	}
+
 	buf, err := io.ReadAll(req.Body)
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusBadRequest)
@@ -131,9 +168,19 @@ func (m *UpdateHandlers) UpdateBatchMetrics(res http.ResponseWriter, req *http.R
 		return
 	}
 
+	if !atomic {
+		m.updateValidMetrics(res, req, metrics)
+		return
+	}
+
 	updatedMetrics, err := m.metricService.UpdateMetrics(req.Context(), metrics)
+	var batchErr *errors2.BatchError
+	if errors.As(err, &batchErr) {
+		writeBatchResponse(res, http.StatusBadRequest, batchUpdateResponse{Errors: toBatchItemErrors(batchErr)})
+		return
+	}
 	if err != nil {
-		http.Error(res, "invalid counter operation", http.StatusBadRequest)
+		http.Error(res, err.Error(), errorStatusCode(err))
 		return
 	}
 
@@ -147,6 +194,43 @@ func (m *UpdateHandlers) UpdateBatchMetrics(res http.ResponseWriter, req *http.R
 	res.Write(jsonData)
 }
 
+func (m *UpdateHandlers) updateValidMetrics(res http.ResponseWriter, req *http.Request, metrics []models.Metrics) {
+	updatedMetrics, batchErr, err := m.metricService.UpdateValidMetrics(req.Context(), metrics)
+	if err != nil {
+		http.Error(res, err.Error(), errorStatusCode(err))
+		return
+	}
+
+	statusCode := http.StatusOK
+	if len(updatedMetrics) == 0 && batchErr != nil {
+		statusCode = http.StatusBadRequest
+	}
+	writeBatchResponse(res, statusCode, batchUpdateResponse{Metrics: updatedMetrics, Errors: toBatchItemErrors(batchErr)})
+}
+
+func toBatchItemErrors(batchErr *errors2.BatchError) []batchItemError {
+	if batchErr == nil {
+		return nil
+	}
+
+	itemErrors := make([]batchItemError, 0, len(batchErr.Items))
+	for _, item := range batchErr.Items {
+		itemErrors = append(itemErrors, batchItemError{Index: item.Index, Error: item.Error()})
+	}
+	return itemErrors
+}
+
+func writeBatchResponse(res http.ResponseWriter, statusCode int, response batchUpdateResponse) {
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		http.Error(res, "invalid marshaling", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(statusCode)
+	res.Write(jsonData)
+}
+
 // UpdateMetric обработка ендпоинта POST /update/{metricType}/{metricName}/{metricValue}.
 // Записывает в репозиторий метрику из квери.
 //
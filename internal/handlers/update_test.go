@@ -1,12 +1,21 @@
 package handlers_test
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"go-svc-metrics/internal/config"
 	"go-svc-metrics/internal/domain/mocks"
+	"go-svc-metrics/internal/router"
+	"go-svc-metrics/internal/service"
+	"go-svc-metrics/internal/utils/crypto"
+	errors2 "go-svc-metrics/internal/utils/errors"
 	"go-svc-metrics/internal/utils/helpers"
 	"go-svc-metrics/models"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -25,12 +34,28 @@ func TestUpdateBatchMetricslHandler(t *testing.T) {
 			metrics: []models.Metrics{helpers.GaugeMetric, helpers.CounterMetric},
 			code:    http.StatusOK,
 		},
+		{
+			name:    "Negative test update batch model handler without gauge value",
+			metrics: []models.Metrics{helpers.CounterMetric, helpers.GaugeMetricRequest},
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "Negative test update batch model handler with invalid type",
+			metrics: []models.Metrics{{ID: helpers.ValidGaugeID, MType: "unknown", Value: &helpers.ValidGaugeValue}},
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "Negative test update batch model handler when storage is unavailable",
+			metrics: []models.Metrics{helpers.CounterMetric},
+			code:    http.StatusServiceUnavailable,
+		},
 	}
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockMetricRepo := mocks.NewMockMetricRepo(ctrl)
 	mockMetricRepo.EXPECT().UpdateMetrics(gomock.Any(), []models.Metrics{helpers.GaugeMetric, helpers.CounterMetric}).Return([]models.Metrics{helpers.GaugeMetric, helpers.CounterMetric}, nil).AnyTimes()
+	mockMetricRepo.EXPECT().UpdateMetrics(gomock.Any(), []models.Metrics{helpers.CounterMetric}).Return(nil, errors2.ErrStorageUnavailable).AnyTimes()
 
 	ts := NewTestServer(mockMetricRepo)
 	defer ts.Close()
@@ -48,6 +73,83 @@ func TestUpdateBatchMetricslHandler(t *testing.T) {
 	}
 }
 
+type batchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+type batchUpdateResponse struct {
+	Metrics []models.Metrics `json:"metrics"`
+	Errors  []batchItemError `json:"errors"`
+}
+
+func TestUpdateBatchMetricsPartialHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		metrics []models.Metrics
+		code    int
+		applied int
+		errors  []batchItemError
+	}{
+		{
+			name:    "positive test partial batch applies valid metrics",
+			path:    "/updates/?atomic=false",
+			metrics: []models.Metrics{helpers.CounterMetric, helpers.GaugeMetricRequest},
+			code:    http.StatusOK,
+			applied: 1,
+			errors:  []batchItemError{{Index: 1, Error: "metrics[1].value: missing metric value"}},
+		},
+		{
+			name:    "Negative test partial batch without valid metrics",
+			path:    "/updates/?atomic=false",
+			metrics: []models.Metrics{helpers.GaugeMetricRequest},
+			code:    http.StatusBadRequest,
+			errors:  []batchItemError{{Index: 0, Error: "metrics[0].value: missing metric value"}},
+		},
+		{
+			name:    "Negative test atomic batch reports errors by index",
+			path:    "/updates/",
+			metrics: []models.Metrics{helpers.CounterMetric, helpers.GaugeMetricRequest},
+			code:    http.StatusBadRequest,
+			errors:  []batchItemError{{Index: 1, Error: "metrics[1].value: missing metric value"}},
+		},
+		{
+			name:    "Negative test batch with invalid atomic parameter",
+			path:    "/updates/?atomic=maybe",
+			metrics: []models.Metrics{helpers.CounterMetric},
+			code:    http.StatusBadRequest,
+		},
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetricRepo := mocks.NewMockMetricRepo(ctrl)
+	mockMetricRepo.EXPECT().UpdateMetrics(gomock.Any(), models.BatchMetrics{helpers.CounterMetric}).Return([]models.Metrics{helpers.CounterMetric}, nil).Times(1)
+
+	ts := NewTestServer(mockMetricRepo)
+	defer ts.Close()
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			metricJSON, err := json.Marshal(v.metrics)
+			require.NoError(t, err)
+
+			resp, body := helpers.TestRequest(t, ts, http.MethodPost, v.path, metricJSON)
+			defer resp.Body.Close()
+			assert.Equal(t, v.code, resp.StatusCode)
+			if v.errors == nil {
+				return
+			}
+
+			var response batchUpdateResponse
+			require.NoError(t, json.Unmarshal([]byte(body), &response))
+			assert.Len(t, response.Metrics, v.applied)
+			assert.Equal(t, v.errors, response.Errors)
+		})
+	}
+}
+
 func TestUpdateModelHandler(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -163,3 +265,64 @@ func TestUpdateHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateBatchMetricsHashHandler(t *testing.T) {
+	const key = "SecretKey"
+	tests := []struct {
+		name string
+		hash func(body []byte) string
+		code int
+	}{
+		{
+			name: "positive test update batch with valid HashSHA256",
+			hash: func(body []byte) string { return hex.EncodeToString(crypto.GetHash(key, body)) },
+			code: http.StatusOK,
+		},
+		{
+			name: "Negative test update batch with invalid HashSHA256",
+			hash: func(body []byte) string { return hex.EncodeToString(crypto.GetHash("OtherKey", body)) },
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Negative test update batch without HashSHA256",
+			hash: func(body []byte) string { return "" },
+			code: http.StatusBadRequest,
+		},
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetricRepo := mocks.NewMockMetricRepo(ctrl)
+	mockMetricRepo.EXPECT().UpdateMetrics(gomock.Any(), []models.Metrics{helpers.GaugeMetric}).Return([]models.Metrics{helpers.GaugeMetric}, nil).AnyTimes()
+
+	_ = config.InitDefaultEnv()
+	configServe, _ := config.InitConfig()
+	configServe.Key = new(string)
+	*configServe.Key = key
+	r, err := router.NewRouter(service.NewMetricService(mockMetricRepo), configServe)
+	require.NoError(t, err)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			metricJSON, err := json.Marshal([]models.Metrics{helpers.GaugeMetric})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/updates/", bytes.NewBuffer(metricJSON))
+			require.NoError(t, err)
+			req.Header.Set("HashSHA256", v.hash(metricJSON))
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, v.code, resp.StatusCode)
+
+			if v.code == http.StatusOK {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, hex.EncodeToString(crypto.GetHash(key, body)), resp.Header.Get("HashSHA256"))
+			}
+		})
+	}
+}
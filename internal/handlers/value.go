@@ -2,13 +2,12 @@ package handlers
 
 import (
 	"bytes"
-	"database/sql"
 	"encoding/json"
-	"errors"
 	"go-svc-metrics/internal/service"
 	errors2 "go-svc-metrics/internal/utils/errors"
 	"go-svc-metrics/models"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -23,11 +22,14 @@ func NewValueHandlers(metricService *service.MetricService) *ValueHandlers {
 }
 
 // GetMetricValue обработка ендпоинта GET /value/{metricType}/{metricName}.
-// Возвращает значение метрики.
+// Возвращает значение метрики. Для histogram возвращается количество наблюдений,
+// а с параметром quantile - оценка квантиля. Серия с метками выбирается параметрами label=name=value.
 //
 // Example:
 //
 //	http://localhost:8080/value/counter/CounterMetric
+//	http://localhost:8080/value/histogram/Latency?quantile=0.99
+//	http://localhost:8080/value/gauge/CPUutilization?label=cpu=1
 //
 // Output:
 //
@@ -35,14 +37,26 @@ func NewValueHandlers(metricService *service.MetricService) *ValueHandlers {
 func (m *ValueHandlers) GetMetricValue(res http.ResponseWriter, req *http.Request) {
 	metricTypeFromPath := chi.URLParam(req, MetricTypePath)
 	metricNameFromPath := chi.URLParam(req, MetricNamePath)
-	value, err := m.metricService.GetMetricValue(req.Context(), metricTypeFromPath, metricNameFromPath)
+
+	labels, err := parseLabels(req.URL.Query())
 	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			http.Error(res, err.Error(), http.StatusNotFound)
-		case errors.Is(err, errors2.ErrInvalidMetricVType):
-			http.Error(res, err.Error(), http.StatusBadRequest)
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var value string
+	if req.URL.Query().Has("quantile") {
+		q, parseErr := strconv.ParseFloat(req.URL.Query().Get("quantile"), 64)
+		if parseErr != nil {
+			http.Error(res, errors2.ErrInvalidQuantile.Error(), http.StatusBadRequest)
+			return
 		}
+		value, err = m.metricService.GetMetricQuantile(req.Context(), metricTypeFromPath, metricNameFromPath, labels, q)
+	} else {
+		value, err = m.metricService.GetMetricValue(req.Context(), metricTypeFromPath, metricNameFromPath, labels)
+	}
+	if err != nil {
+		http.Error(res, err.Error(), errorStatusCode(err))
 		return
 	}
 
@@ -93,7 +107,7 @@ func (m *ValueHandlers) GetMetric(res http.ResponseWriter, req *http.Request) {
 
 	metric, err := m.metricService.GetMetric(req.Context(), metricReq)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusNotFound)
+		http.Error(res, err.Error(), errorStatusCode(err))
 		return
 	}
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"go-svc-metrics/internal/domain/mocks"
+	"go-svc-metrics/internal/sketch"
 	"go-svc-metrics/internal/utils/helpers"
 	"go-svc-metrics/models"
 	"net/http"
@@ -77,6 +78,129 @@ func TestValueHandler(t *testing.T) {
 	}
 }
 
+func TestValueHandlerWithLabels(t *testing.T) {
+	value := 42.5
+	stored := models.Metrics{ID: "CPUutilization", MType: models.Gauge, Value: &value, Labels: models.Labels{"cpu": "1"}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetricRepo := mocks.NewMockMetricRepo(ctrl)
+	mockMetricRepo.EXPECT().GetMetric(gomock.Any(), models.Metrics{ID: "CPUutilization", MType: models.Gauge, Labels: models.Labels{"cpu": "1"}}).Return(stored, nil)
+	ts := NewTestServer(mockMetricRepo)
+	defer ts.Close()
+
+	resp, body := helpers.TestRequest(t, ts, http.MethodGet, "/value/gauge/CPUutilization?label=cpu=1", []byte{})
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "42.5", body)
+
+	resp, _ = helpers.TestRequest(t, ts, http.MethodGet, "/value/gauge/CPUutilization?label=cpu", []byte{})
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestValueQuantileHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		metricType string
+		query      string
+		code       int
+		body       string
+	}{
+		{
+			name:       "positive test histogram count",
+			metricType: models.Histogram,
+			code:       http.StatusOK,
+			body:       "4",
+		},
+		{
+			name:       "positive test histogram quantile",
+			metricType: models.Histogram,
+			query:      "?quantile=0.75",
+			code:       http.StatusOK,
+			body:       "0.1",
+		},
+		{
+			name:       "Negative test histogram quantile out of range",
+			metricType: models.Histogram,
+			query:      "?quantile=2",
+			code:       http.StatusBadRequest,
+		},
+		{
+			name:       "Negative test quantile for gauge",
+			metricType: models.Gauge,
+			query:      "?quantile=0.5",
+			code:       http.StatusBadRequest,
+		},
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetricRepo := mocks.NewMockMetricRepo(ctrl)
+	mockMetricRepo.EXPECT().GetMetric(gomock.Any(), models.Metrics{ID: histogramMetric.ID, MType: models.Histogram}).Return(histogramMetric, nil).AnyTimes()
+	ts := NewTestServer(mockMetricRepo)
+	defer ts.Close()
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			path := fmt.Sprintf("/value/%s/%s%s", v.metricType, histogramMetric.ID, v.query)
+			resp, body := helpers.TestRequest(t, ts, http.MethodGet, path, []byte{})
+			defer resp.Body.Close()
+			assert.Equal(t, v.code, resp.StatusCode)
+			if v.code == http.StatusOK {
+				assert.Equal(t, v.body, body)
+			}
+		})
+	}
+}
+
+func TestSetMetricHandler(t *testing.T) {
+	hll, err := sketch.NewHyperLogLog(sketch.DefaultPrecision)
+	require.NoError(t, err)
+	hll.Add("alice")
+	stored := models.Metrics{ID: "Users", MType: models.Set, HLL: hll}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetricRepo := mocks.NewMockMetricRepo(ctrl)
+	mockMetricRepo.EXPECT().UpdateMetrics(gomock.Any(), models.BatchMetrics{stored}).Return([]models.Metrics{stored}, nil).Times(2)
+	mockMetricRepo.EXPECT().GetMetric(gomock.Any(), models.Metrics{ID: "Users", MType: models.Set}).Return(stored, nil).AnyTimes()
+	ts := NewTestServer(mockMetricRepo)
+	defer ts.Close()
+
+	resp, _ := helpers.TestRequest(t, ts, http.MethodPost, "/update/set/Users/alice", []byte{})
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	metricJSON, err := json.Marshal([]models.Metrics{{ID: "Users", MType: models.Set, Members: []string{"alice", "alice"}}})
+	require.NoError(t, err)
+	resp, _ = helpers.TestRequest(t, ts, http.MethodPost, "/updates/", metricJSON)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body := helpers.TestRequest(t, ts, http.MethodGet, "/value/set/Users", []byte{})
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "1", body)
+
+	metricJSON, err = json.Marshal(models.Metrics{ID: "Users", MType: models.Set})
+	require.NoError(t, err)
+	resp, body = helpers.TestRequest(t, ts, http.MethodPost, "/value/", metricJSON)
+	defer resp.Body.Close()
+	var metric models.Metrics
+	require.NoError(t, json.Unmarshal([]byte(body), &metric))
+	require.NotNil(t, metric.Value)
+	assert.Equal(t, 1.0, *metric.Value)
+
+	metricJSON, err = json.Marshal([]models.Metrics{{ID: "Users", MType: models.Set}})
+	require.NoError(t, err)
+	resp, _ = helpers.TestRequest(t, ts, http.MethodPost, "/updates/", metricJSON)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 func TestGetModelHandler(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-svc-metrics/internal/service"
+	"net/http"
+	"time"
+)
+
+// watchHeartbeatInterval интервал комментариев SSE, чтобы прокси не закрывали соединение.
+const watchHeartbeatInterval = 15 * time.Second
+
+// WatchHandlers хранит слой сервиса.
+type WatchHandlers struct {
+	metricService *service.MetricService
+}
+
+// NewWatchHandlers создает и возвращает новый WatchHandlers.
+func NewWatchHandlers(metricService *service.MetricService) *WatchHandlers {
+	return &WatchHandlers{metricService: metricService}
+}
+
+// Watch обработка ендпоинта GET /api/v1/watch .
+// Отправляет принятые обновления метрик как Server-Sent Events.
+// Фильтры: prefix - префикс имени метрики, type - тип метрики.
+//
+// Example:
+//
+//	http://localhost:8080/api/v1/watch?prefix=Heap&type=gauge
+//
+// Output:
+//
+//	event: metric
+//	data: {"id":"HeapAlloc","type":"gauge","value":1024}
+func (m *WatchHandlers) Watch(res http.ResponseWriter, req *http.Request) {
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		http.Error(res, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	filter := service.WatchFilter{Prefix: req.URL.Query().Get("prefix"), MType: req.URL.Query().Get("type")}
+	updates, err := m.metricService.Watch(req.Context(), filter)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case metric, ok := <-updates:
+			if !ok {
+				return
+			}
+			jsonData, err := json.Marshal(metric)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(res, "event: metric\ndata: %s\n\n", jsonData); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
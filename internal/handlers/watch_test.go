@@ -0,0 +1,59 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"go-svc-metrics/internal/domain/mocks"
+	"go-svc-metrics/internal/utils/helpers"
+	"go-svc-metrics/models"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMetricRepo(ctrl)
+	mockRepo.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, metrics []models.Metrics) ([]models.Metrics, error) {
+			return metrics, nil
+		},
+	).Times(2)
+
+	ts := NewTestServer(mockRepo)
+	defer ts.Close()
+
+	resp, _ := helpers.TestRequest(t, ts, http.MethodGet, "/api/v1/watch?type=unknown", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/v1/watch?prefix=Gauge&type=gauge", nil)
+	require.NoError(t, err)
+	watchResp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer watchResp.Body.Close()
+	assert.Equal(t, "text/event-stream", watchResp.Header.Get("Content-Type"))
+
+	resp, _ = helpers.TestRequest(t, ts, http.MethodPost, "/update/counter/CounterMetric/4", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = helpers.TestRequest(t, ts, http.MethodPost, "/update/gauge/GaugeMetric/1.5", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var events []string
+	scanner := bufio.NewScanner(watchResp.Body)
+	for scanner.Scan() && len(events) < 2 {
+		if line := scanner.Text(); line != "" && !strings.HasPrefix(line, ":") {
+			events = append(events, line)
+		}
+	}
+	assert.Equal(t, []string{"event: metric", `data: {"id":"GaugeMetric","type":"gauge","value":1.5}`}, events)
+}
@@ -0,0 +1,201 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"go-svc-metrics/internal/utils/crypto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// HashMetadataKey ключ метаданных с HMAC-SHA256 подписью запроса.
+const HashMetadataKey = "hashsha256"
+
+// hashField поле сообщения потока с HMAC-SHA256 подписью.
+// В потоке метаданные передаются один раз, поэтому каждое сообщение подписывается отдельно.
+const hashField = "hash"
+
+// signMessage подписывает детерминированное бинарное представление сообщения.
+func signMessage(key string, message any) ([]byte, error) {
+	protoMessage, ok := message.(proto.Message)
+	if !ok {
+		return nil, status.Error(codes.Internal, "message is not a protobuf message")
+	}
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(protoMessage)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.GetHash(key, data), nil
+}
+
+// NewHashInterceptor проверяет подпись запроса из метаданных HashSHA256.
+func NewHashInterceptor(key string) func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing HashSHA256")
+		}
+
+		values := md.Get(HashMetadataKey)
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "missing HashSHA256")
+		}
+
+		messageMAC, err := hex.DecodeString(values[0])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid HashSHA256")
+		}
+
+		expectedMAC, err := signMessage(key, req)
+		if err != nil {
+			return nil, err
+		}
+		if !hmac.Equal(messageMAC, expectedMAC) {
+			return nil, status.Error(codes.Unauthenticated, "invalid HashSHA256")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func NewHashClientInterceptor(key string) func(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		hash, err := signMessage(key, req)
+		if err != nil {
+			return err
+		}
+
+		newCtx := metadata.AppendToOutgoingContext(ctx, HashMetadataKey, hex.EncodeToString(hash))
+		return invoker(newCtx, method, req, reply, cc, opts...)
+	}
+}
+
+// streamHashField возвращает поле подписи сообщения потока.
+func streamHashField(message any) (proto.Message, protoreflect.FieldDescriptor, error) {
+	protoMessage, ok := message.(proto.Message)
+	if !ok {
+		return nil, nil, status.Error(codes.Internal, "message is not a protobuf message")
+	}
+
+	fd := protoMessage.ProtoReflect().Descriptor().Fields().ByName(hashField)
+	if fd == nil || fd.Kind() != protoreflect.StringKind {
+		return nil, nil, status.Error(codes.Internal, "message has no hash field")
+	}
+	return protoMessage, fd, nil
+}
+
+// signStreamMessage подписывает сообщение потока без поля подписи.
+func signStreamMessage(key string, message proto.Message, fd protoreflect.FieldDescriptor) ([]byte, error) {
+	unsigned := proto.Clone(message)
+	unsigned.ProtoReflect().Clear(fd)
+	return signMessage(key, unsigned)
+}
+
+type hashServerStream struct {
+	grpc.ServerStream
+	key string
+}
+
+func (s *hashServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	message, fd, err := streamHashField(m)
+	if err != nil {
+		return err
+	}
+
+	messageMAC, err := hex.DecodeString(message.ProtoReflect().Get(fd).String())
+	if err != nil || len(messageMAC) == 0 {
+		return status.Error(codes.Unauthenticated, "invalid HashSHA256")
+	}
+
+	expectedMAC, err := signStreamMessage(s.key, message, fd)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(messageMAC, expectedMAC) {
+		return status.Error(codes.Unauthenticated, "invalid HashSHA256")
+	}
+	return nil
+}
+
+// NewHashStreamInterceptor проверяет подпись каждого сообщения потока.
+func NewHashStreamInterceptor(key string) func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublicMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		return handler(srv, &hashServerStream{ServerStream: ss, key: key})
+	}
+}
+
+type hashClientStream struct {
+	grpc.ClientStream
+	key string
+}
+
+func (s *hashClientStream) SendMsg(m any) error {
+	message, fd, err := streamHashField(m)
+	if err != nil {
+		return err
+	}
+
+	hash, err := signStreamMessage(s.key, message, fd)
+	if err != nil {
+		return err
+	}
+
+	message.ProtoReflect().Set(fd, protoreflect.ValueOfString(hex.EncodeToString(hash)))
+	return s.ClientStream.SendMsg(m)
+}
+
+// NewHashStreamClientInterceptor подписывает каждое сообщение потока.
+func NewHashStreamClientInterceptor(key string) func(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &hashClientStream{ClientStream: clientStream, key: key}, nil
+	}
+}
@@ -0,0 +1,73 @@
+package interceptors
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type identityKey struct{}
+
+// IdentityFromContext возвращает CN проверенного клиентского сертификата агента.
+func IdentityFromContext(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(identityKey{}).(string)
+	return identity, ok
+}
+
+// ContextWithIdentity сохраняет идентификатор агента в контексте.
+func ContextWithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// NewIdentityInterceptor берет идентификатор агента из клиентского сертификата mTLS.
+func NewIdentityInterceptor() func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		identity, err := peerIdentity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ContextWithIdentity(ctx, identity), req)
+	}
+}
+
+type identityServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// NewIdentityStreamInterceptor берет идентификатор агента из клиентского сертификата mTLS для потока.
+func NewIdentityStreamInterceptor() func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		identity, err := peerIdentity(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identityServerStream{ServerStream: ss, ctx: ContextWithIdentity(ss.Context(), identity)})
+	}
+}
+
+func peerIdentity(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "missing peer")
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", status.Error(codes.Unauthenticated, "missing verified client certificate")
+	}
+
+	identity := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+	if identity == "" {
+		return "", status.Error(codes.Unauthenticated, "client certificate has empty common name")
+	}
+	return identity, nil
+}
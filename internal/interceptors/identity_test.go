@@ -0,0 +1,55 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestIdentityInterceptor(t *testing.T) {
+	peerWithCN := func(commonName string) *peer.Peer {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		return &peer.Peer{AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		peer     *peer.Peer
+		identity string
+		code     codes.Code
+	}{
+		{name: "verified client certificate", peer: peerWithCN("agent-1"), identity: "agent-1", code: codes.OK},
+		{name: "empty common name", peer: peerWithCN(""), code: codes.Unauthenticated},
+		{name: "connection without tls", peer: &peer.Peer{}, code: codes.Unauthenticated},
+		{name: "missing peer", code: codes.Unauthenticated},
+	}
+
+	interceptor := NewIdentityInterceptor()
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			ctx := context.Background()
+			if v.peer != nil {
+				ctx = peer.NewContext(ctx, v.peer)
+			}
+
+			var identity string
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+				identity, _ = IdentityFromContext(ctx)
+				return nil, nil
+			})
+			assert.Equal(t, v.code, status.Code(err))
+			assert.Equal(t, v.identity, identity)
+		})
+	}
+}
@@ -16,7 +16,11 @@ func LoggingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo
 	resp, err := handler(ctx, req)
 
 	duration := time.Since(start)
-	logger.Log.Info("REQUEST", zap.String("URI", info.FullMethod), zap.Duration("DURATION", duration))
+	fields := []zap.Field{zap.String("URI", info.FullMethod), zap.Duration("DURATION", duration)}
+	if identity, ok := IdentityFromContext(ctx); ok {
+		fields = append(fields, zap.String("AGENT", identity))
+	}
+	logger.Log.Info("REQUEST", fields...)
 	switch err {
 	case nil:
 		size := proto.Size(resp.(protoreflect.ProtoMessage))
@@ -27,3 +31,19 @@ func LoggingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo
 
 	return resp, err
 }
+
+func LoggingStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+
+	duration := time.Since(start)
+	fields := []zap.Field{zap.String("URI", info.FullMethod), zap.Duration("DURATION", duration)}
+	if identity, ok := IdentityFromContext(ss.Context()); ok {
+		fields = append(fields, zap.String("AGENT", identity))
+	}
+	logger.Log.Info("STREAM", fields...)
+	if err != nil {
+		logger.Log.Warn("STREAM", zap.String("URI", info.FullMethod), zap.Error(err), zap.Duration("DURATION", duration))
+	}
+	return err
+}
@@ -0,0 +1,20 @@
+package interceptors
+
+import "strings"
+
+// publicServices сервисы, которые доступны без подписи и проверки X-Real-IP:
+// пробы health check и отладка через grpcurl.
+var publicServices = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+func isPublicMethod(fullMethod string) bool {
+	for _, service := range publicServices {
+		if strings.HasPrefix(fullMethod, service) {
+			return true
+		}
+	}
+	return false
+}
@@ -15,6 +15,9 @@ import (
 
 func NewRealIPInterceptor(network *net.IPNet) func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
 		if md, ok := metadata.FromIncomingContext(ctx); ok {
 			if isTrustedNetwork(md, network) {
 				return handler(ctx, req)
@@ -24,6 +27,21 @@ func NewRealIPInterceptor(network *net.IPNet) func(ctx context.Context, req any,
 	}
 }
 
+// NewRealIPStreamInterceptor проверяет X-Real-IP при открытии потока.
+func NewRealIPStreamInterceptor(network *net.IPNet) func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isPublicMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		if md, ok := metadata.FromIncomingContext(ss.Context()); ok {
+			if isTrustedNetwork(md, network) {
+				return handler(srv, ss)
+			}
+		}
+		return status.Error(codes.Unauthenticated, "invalid real IP")
+	}
+}
+
 func isTrustedNetwork(md metadata.MD, network *net.IPNet) bool {
 	ipData := md.Get("X-Real-IP")
 	if len(ipData) <= 0 {
@@ -64,8 +82,7 @@ func NewRealIPClientInterceptor(realIP string) func(
 		invoker grpc.UnaryInvoker,
 		opts ...grpc.CallOption,
 	) error {
-		md := metadata.New(map[string]string{"X-Real-IP": realIP})
-		newCtx := metadata.NewOutgoingContext(ctx, md)
+		newCtx := metadata.AppendToOutgoingContext(ctx, "X-Real-IP", realIP)
 
 		err := invoker(newCtx, method, req, reply, cc, opts...)
 		if err != nil {
@@ -74,3 +91,24 @@ func NewRealIPClientInterceptor(realIP string) func(
 		return err
 	}
 }
+
+func NewRealIPStreamClientInterceptor(realIP string) func(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		newCtx := metadata.AppendToOutgoingContext(ctx, "X-Real-IP", realIP)
+		return streamer(newCtx, desc, cc, method, opts...)
+	}
+}
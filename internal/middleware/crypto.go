@@ -3,22 +3,26 @@ package middleware
 import (
 	"bytes"
 	"encoding/hex"
-	"go-svc-metrics/internal/config"
 	"go-svc-metrics/internal/utils/crypto"
 	"io"
 	"net/http"
 )
 
+// HashHeader заголовок с HMAC-SHA256 подписью тела запроса или ответа.
+const HashHeader = "HashSHA256"
+
 type cryptoWriter struct {
-	w    http.ResponseWriter
-	key  string
-	body []byte
+	w          http.ResponseWriter
+	key        string
+	statusCode int
+	body       bytes.Buffer
 }
 
 func newCryptoWriter(w http.ResponseWriter, key string) *cryptoWriter {
 	return &cryptoWriter{
-		w:   w,
-		key: key,
+		w:          w,
+		key:        key,
+		statusCode: http.StatusOK,
 	}
 }
 
@@ -27,62 +31,57 @@ func (c *cryptoWriter) Header() http.Header {
 }
 
 func (c *cryptoWriter) Write(p []byte) (int, error) {
-	cryptData, err := crypto.EncryptData(c.key, p)
-	if err != nil {
-		return 0, err
-	}
-
-	return c.w.Write(cryptData)
+	return c.body.Write(p)
 }
 
 func (c *cryptoWriter) WriteHeader(statusCode int) {
-	if statusCode < 300 {
-		hash := crypto.GetHash(c.key, c.body)
-		c.w.Header().Set("HashSHA256", hex.EncodeToString(hash))
+	c.statusCode = statusCode
+}
+
+// flush подписывает накопленное тело ответа и отправляет его клиенту.
+func (c *cryptoWriter) flush() {
+	if c.statusCode < 300 {
+		hash := crypto.GetHash(c.key, c.body.Bytes())
+		c.w.Header().Set(HashHeader, hex.EncodeToString(hash))
 	}
-	c.w.WriteHeader(statusCode)
+	c.w.WriteHeader(c.statusCode)
+	c.w.Write(c.body.Bytes())
 }
 
+// CryptoMiddleware проверяет подпись тела запроса и подписывает тело ответа.
+// Если ключ не задан, запросы пропускаются без проверки.
 type CryptoMiddleware struct {
-	Config *config.Config
+	Key string
 }
 
 func (c *CryptoMiddleware) GetCryptoMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ow := w
-
-		HashSHA256Header := r.Header.Get("HashSHA256")
-		if HashSHA256Header != "" {
-			cw := newCryptoWriter(w, *c.Config.Key)
-			ow = cw
-
-			bodyBytes, err := io.ReadAll(r.Body)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			r.Body.Close()
-			messageMAC, err := hex.DecodeString(HashSHA256Header)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		if c.Key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-			newBody, err := crypto.DecryptData(*c.Config.Key, bodyBytes)
-			if ok := crypto.ValidMAC(newBody, messageMAC, *c.Config.Key); !ok {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		messageMAC, err := hex.DecodeString(r.Header.Get(HashHeader))
+		if err != nil || len(messageMAC) == 0 {
+			http.Error(w, "invalid HashSHA256 header", http.StatusBadRequest)
+			return
+		}
 
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		bodyBytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		r.Body.Close()
 
-			r.Body = io.NopCloser(bytes.NewBuffer(newBody))
+		if ok := crypto.ValidMAC(bodyBytes, messageMAC, c.Key); !ok {
+			http.Error(w, "invalid HashSHA256 header", http.StatusBadRequest)
+			return
 		}
+		r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 
-		next.ServeHTTP(ow, r)
+		cw := newCryptoWriter(w, c.Key)
+		next.ServeHTTP(cw, r)
+		cw.flush()
 	})
 }
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/hex"
+	"go-svc-metrics/internal/utils/crypto"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoMiddleware(t *testing.T) {
+	const key = "SecretKey"
+	body := []byte(`[{"id":"Alloc","type":"gauge","value":1}]`)
+	response := []byte(`[{"id":"Alloc","type":"gauge","value":1}]`)
+
+	tests := []struct {
+		name       string
+		key        string
+		hash       string
+		statusCode int
+		signed     bool
+	}{
+		{name: "valid signature", key: key, hash: hex.EncodeToString(crypto.GetHash(key, body)), statusCode: http.StatusOK, signed: true},
+		{name: "missing signature", key: key, statusCode: http.StatusBadRequest},
+		{name: "bad signature", key: key, hash: hex.EncodeToString(crypto.GetHash("OtherKey", body)), statusCode: http.StatusBadRequest},
+		{name: "not hex signature", key: key, hash: "not-hex", statusCode: http.StatusBadRequest},
+		{name: "key is not set", statusCode: http.StatusOK},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			var received []byte
+			middleware := CryptoMiddleware{Key: v.key}
+			handler := middleware.GetCryptoMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var err error
+				received, err = io.ReadAll(r.Body)
+				require.NoError(t, err)
+				w.WriteHeader(http.StatusOK)
+				w.Write(response)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(body))
+			if v.hash != "" {
+				req.Header.Set(HashHeader, v.hash)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, v.statusCode, rec.Code)
+			if v.statusCode != http.StatusOK {
+				assert.Nil(t, received)
+				return
+			}
+			assert.Equal(t, body, received)
+			assert.Equal(t, response, rec.Body.Bytes())
+
+			if !v.signed {
+				assert.Empty(t, rec.Header().Get(HashHeader))
+				return
+			}
+			// Ответ подписан тем же ключом.
+			responseMAC, err := hex.DecodeString(rec.Header().Get(HashHeader))
+			require.NoError(t, err)
+			assert.True(t, crypto.ValidMAC(rec.Body.Bytes(), responseMAC, key))
+		})
+	}
+}
@@ -6,36 +6,12 @@ import (
 	"go-svc-metrics/internal/utils/crypto"
 	"io"
 	"net/http"
+	"strconv"
 )
 
-type cryptoRSAWriter struct {
-	w          http.ResponseWriter
-	privateKey *rsa.PrivateKey
-}
-
-func newCryptoRSAWriter(w http.ResponseWriter, privateKey *rsa.PrivateKey) *cryptoRSAWriter {
-	return &cryptoRSAWriter{
-		w:          w,
-		privateKey: privateKey,
-	}
-}
-
-func (c *cryptoRSAWriter) Header() http.Header {
-	return c.w.Header()
-}
-
-func (c *cryptoRSAWriter) Write(p []byte) (int, error) {
-	cryptData, err := crypto.DecryptRSAData(c.privateKey, p)
-	if err != nil {
-		return 0, err
-	}
-
-	return c.w.Write(cryptData)
-}
-
-func (c *cryptoRSAWriter) WriteHeader(statusCode int) {
-	c.w.WriteHeader(statusCode)
-}
+// EncryptionVersionHeader заголовок с версией шифрования тела запроса.
+// Без заголовка тело расшифровывается по блокам RSA, как у старых агентов.
+const EncryptionVersionHeader = "X-Encryption-Version"
 
 type CryptoRSAMiddleware struct {
 	PrivateKey *rsa.PrivateKey
@@ -43,30 +19,35 @@ type CryptoRSAMiddleware struct {
 
 func (c *CryptoRSAMiddleware) GetCryptoRSAMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ow := w
-
-		if c.PrivateKey != nil {
-			cw := newCryptoRSAWriter(w, c.PrivateKey)
-			ow = cw
-
-			bodyBytes, err := io.ReadAll(r.Body)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			r.Body.Close()
-
-			newBody, err := crypto.DecryptRSAData(c.PrivateKey, bodyBytes)
-
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		if c.PrivateKey == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-			r.Body = io.NopCloser(bytes.NewBuffer(newBody))
+		bodyBytes, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		r.Body.Close()
+
+		var newBody []byte
+		switch r.Header.Get(EncryptionVersionHeader) {
+		case "":
+			newBody, err = crypto.DecryptRSAData(c.PrivateKey, bodyBytes)
+		case strconv.Itoa(int(crypto.HybridVersion)):
+			newBody, err = crypto.DecryptHybrid(c.PrivateKey, bodyBytes)
+		default:
+			http.Error(w, "unsupported encryption version", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to decrypt body", http.StatusBadRequest)
+			return
 		}
 
-		next.ServeHTTP(ow, r)
+		r.Body = io.NopCloser(bytes.NewBuffer(newBody))
+		r.Header.Del(EncryptionVersionHeader)
+		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"go-svc-metrics/internal/utils/crypto"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoRSAMiddleware(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "metrics"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+
+	body := bytes.Repeat([]byte(`[{"id":"Alloc","type":"gauge","value":1}]`), 100)
+
+	legacyBody, err := crypto.EncryptRSAData(sha256.New(), cert, body)
+	require.NoError(t, err)
+	hybridBody, err := crypto.EncryptHybrid(&privateKey.PublicKey, body)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		version    string
+		body       []byte
+		statusCode int
+	}{
+		{name: "legacy agent", version: "", body: legacyBody, statusCode: http.StatusOK},
+		{name: "hybrid envelope", version: strconv.Itoa(int(crypto.HybridVersion)), body: hybridBody, statusCode: http.StatusOK},
+		{name: "unknown version", version: "99", body: hybridBody, statusCode: http.StatusBadRequest},
+		{name: "corrupted envelope", version: strconv.Itoa(int(crypto.HybridVersion)), body: hybridBody[:len(hybridBody)-1], statusCode: http.StatusBadRequest},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			var received []byte
+			middleware := CryptoRSAMiddleware{PrivateKey: privateKey}
+			handler := middleware.GetCryptoRSAMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, _ = io.ReadAll(r.Body)
+			}))
+
+			request := httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(v.body))
+			if v.version != "" {
+				request.Header.Set(EncryptionVersionHeader, v.version)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, v.statusCode, recorder.Code)
+			if v.statusCode == http.StatusOK {
+				assert.Equal(t, body, received)
+			}
+		})
+	}
+}
@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.10
+// 	protoc-gen-go v1.36.6
 // 	protoc        v3.21.12
 // source: proto/metric.proto
 
@@ -28,6 +28,14 @@ type MetricMessage struct {
 	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
 	Delta         *int64                 `protobuf:"varint,3,opt,name=delta,proto3,oneof" json:"delta,omitempty"`
 	Value         *float64               `protobuf:"fixed64,4,opt,name=Value,proto3,oneof" json:"Value,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Buckets       []float64              `protobuf:"fixed64,6,rep,packed,name=buckets,proto3" json:"buckets,omitempty"`
+	Counts        []uint64               `protobuf:"varint,7,rep,packed,name=counts,proto3" json:"counts,omitempty"`
+	Sum           *float64               `protobuf:"fixed64,8,opt,name=sum,proto3,oneof" json:"sum,omitempty"`
+	Count         *uint64                `protobuf:"varint,9,opt,name=count,proto3,oneof" json:"count,omitempty"`
+	Sketch        *Sketch                `protobuf:"bytes,10,opt,name=sketch,proto3" json:"sketch,omitempty"`
+	Members       []string               `protobuf:"bytes,11,rep,name=members,proto3" json:"members,omitempty"`
+	Hll           *HyperLogLog           `protobuf:"bytes,12,opt,name=hll,proto3" json:"hll,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -90,6 +98,206 @@ func (x *MetricMessage) GetValue() float64 {
 	return 0
 }
 
+func (x *MetricMessage) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *MetricMessage) GetBuckets() []float64 {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *MetricMessage) GetCounts() []uint64 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+func (x *MetricMessage) GetSum() float64 {
+	if x != nil && x.Sum != nil {
+		return *x.Sum
+	}
+	return 0
+}
+
+func (x *MetricMessage) GetCount() uint64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+func (x *MetricMessage) GetSketch() *Sketch {
+	if x != nil {
+		return x.Sketch
+	}
+	return nil
+}
+
+func (x *MetricMessage) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *MetricMessage) GetHll() *HyperLogLog {
+	if x != nil {
+		return x.Hll
+	}
+	return nil
+}
+
+type Sketch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alpha         float64                `protobuf:"fixed64,1,opt,name=alpha,proto3" json:"alpha,omitempty"`
+	Positive      map[int32]uint64       `protobuf:"bytes,2,rep,name=positive,proto3" json:"positive,omitempty" protobuf_key:"zigzag32,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Negative      map[int32]uint64       `protobuf:"bytes,3,rep,name=negative,proto3" json:"negative,omitempty" protobuf_key:"zigzag32,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	ZeroCount     uint64                 `protobuf:"varint,4,opt,name=zero_count,json=zeroCount,proto3" json:"zero_count,omitempty"`
+	Sum           float64                `protobuf:"fixed64,5,opt,name=sum,proto3" json:"sum,omitempty"`
+	Min           float64                `protobuf:"fixed64,6,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64                `protobuf:"fixed64,7,opt,name=max,proto3" json:"max,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sketch) Reset() {
+	*x = Sketch{}
+	mi := &file_proto_metric_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sketch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sketch) ProtoMessage() {}
+
+func (x *Sketch) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sketch.ProtoReflect.Descriptor instead.
+func (*Sketch) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Sketch) GetAlpha() float64 {
+	if x != nil {
+		return x.Alpha
+	}
+	return 0
+}
+
+func (x *Sketch) GetPositive() map[int32]uint64 {
+	if x != nil {
+		return x.Positive
+	}
+	return nil
+}
+
+func (x *Sketch) GetNegative() map[int32]uint64 {
+	if x != nil {
+		return x.Negative
+	}
+	return nil
+}
+
+func (x *Sketch) GetZeroCount() uint64 {
+	if x != nil {
+		return x.ZeroCount
+	}
+	return 0
+}
+
+func (x *Sketch) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *Sketch) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *Sketch) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+type HyperLogLog struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Precision     uint32                 `protobuf:"varint,1,opt,name=precision,proto3" json:"precision,omitempty"`
+	Registers     []byte                 `protobuf:"bytes,2,opt,name=registers,proto3" json:"registers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HyperLogLog) Reset() {
+	*x = HyperLogLog{}
+	mi := &file_proto_metric_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HyperLogLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HyperLogLog) ProtoMessage() {}
+
+func (x *HyperLogLog) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HyperLogLog.ProtoReflect.Descriptor instead.
+func (*HyperLogLog) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HyperLogLog) GetPrecision() uint32 {
+	if x != nil {
+		return x.Precision
+	}
+	return 0
+}
+
+func (x *HyperLogLog) GetRegisters() []byte {
+	if x != nil {
+		return x.Registers
+	}
+	return nil
+}
+
 type BatchMetricsMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Metrics       []*MetricMessage       `protobuf:"bytes,1,rep,name=metrics,proto3" json:"metrics,omitempty"`
@@ -99,7 +307,7 @@ type BatchMetricsMessage struct {
 
 func (x *BatchMetricsMessage) Reset() {
 	*x = BatchMetricsMessage{}
-	mi := &file_proto_metric_proto_msgTypes[1]
+	mi := &file_proto_metric_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -111,7 +319,7 @@ func (x *BatchMetricsMessage) String() string {
 func (*BatchMetricsMessage) ProtoMessage() {}
 
 func (x *BatchMetricsMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_metric_proto_msgTypes[1]
+	mi := &file_proto_metric_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -124,7 +332,7 @@ func (x *BatchMetricsMessage) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BatchMetricsMessage.ProtoReflect.Descriptor instead.
 func (*BatchMetricsMessage) Descriptor() ([]byte, []int) {
-	return file_proto_metric_proto_rawDescGZIP(), []int{1}
+	return file_proto_metric_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *BatchMetricsMessage) GetMetrics() []*MetricMessage {
@@ -134,26 +342,234 @@ func (x *BatchMetricsMessage) GetMetrics() []*MetricMessage {
 	return nil
 }
 
+type StreamMetricsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Batch         *BatchMetricsMessage   `protobuf:"bytes,1,opt,name=batch,proto3" json:"batch,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamMetricsRequest) Reset() {
+	*x = StreamMetricsRequest{}
+	mi := &file_proto_metric_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamMetricsRequest) ProtoMessage() {}
+
+func (x *StreamMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamMetricsRequest.ProtoReflect.Descriptor instead.
+func (*StreamMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StreamMetricsRequest) GetBatch() *BatchMetricsMessage {
+	if x != nil {
+		return x.Batch
+	}
+	return nil
+}
+
+func (x *StreamMetricsRequest) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type StreamAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamAck) Reset() {
+	*x = StreamAck{}
+	mi := &file_proto_metric_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamAck) ProtoMessage() {}
+
+func (x *StreamAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamAck.ProtoReflect.Descriptor instead.
+func (*StreamAck) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StreamAck) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *StreamAck) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Hash          string                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_proto_metric_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
 var File_proto_metric_proto protoreflect.FileDescriptor
 
 const file_proto_metric_proto_rawDesc = "" +
 	"\n" +
-	"\x12proto/metric.proto\x12\x06metric\x1a\x1bgoogle/protobuf/empty.proto\"}\n" +
+	"\x12proto/metric.proto\x12\x06metric\x1a\x1bgoogle/protobuf/empty.proto\"\xd2\x03\n" +
 	"\rMetricMessage\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04type\x18\x02 \x01(\tR\x04type\x12\x19\n" +
 	"\x05delta\x18\x03 \x01(\x03H\x00R\x05delta\x88\x01\x01\x12\x19\n" +
-	"\x05Value\x18\x04 \x01(\x01H\x01R\x05Value\x88\x01\x01B\b\n" +
+	"\x05Value\x18\x04 \x01(\x01H\x01R\x05Value\x88\x01\x01\x129\n" +
+	"\x06labels\x18\x05 \x03(\v2!.metric.MetricMessage.LabelsEntryR\x06labels\x12\x18\n" +
+	"\abuckets\x18\x06 \x03(\x01R\abuckets\x12\x16\n" +
+	"\x06counts\x18\a \x03(\x04R\x06counts\x12\x15\n" +
+	"\x03sum\x18\b \x01(\x01H\x02R\x03sum\x88\x01\x01\x12\x19\n" +
+	"\x05count\x18\t \x01(\x04H\x03R\x05count\x88\x01\x01\x12&\n" +
+	"\x06sketch\x18\n" +
+	" \x01(\v2\x0e.metric.SketchR\x06sketch\x12\x18\n" +
+	"\amembers\x18\v \x03(\tR\amembers\x12%\n" +
+	"\x03hll\x18\f \x01(\v2\x13.metric.HyperLogLogR\x03hll\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\b\n" +
 	"\x06_deltaB\b\n" +
-	"\x06_Value\"F\n" +
+	"\x06_ValueB\x06\n" +
+	"\x04_sumB\b\n" +
+	"\x06_count\"\xe1\x02\n" +
+	"\x06Sketch\x12\x14\n" +
+	"\x05alpha\x18\x01 \x01(\x01R\x05alpha\x128\n" +
+	"\bpositive\x18\x02 \x03(\v2\x1c.metric.Sketch.PositiveEntryR\bpositive\x128\n" +
+	"\bnegative\x18\x03 \x03(\v2\x1c.metric.Sketch.NegativeEntryR\bnegative\x12\x1d\n" +
+	"\n" +
+	"zero_count\x18\x04 \x01(\x04R\tzeroCount\x12\x10\n" +
+	"\x03sum\x18\x05 \x01(\x01R\x03sum\x12\x10\n" +
+	"\x03min\x18\x06 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\a \x01(\x01R\x03max\x1a;\n" +
+	"\rPositiveEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x11R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\x1a;\n" +
+	"\rNegativeEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x11R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"I\n" +
+	"\vHyperLogLog\x12\x1c\n" +
+	"\tprecision\x18\x01 \x01(\rR\tprecision\x12\x1c\n" +
+	"\tregisters\x18\x02 \x01(\fR\tregisters\"F\n" +
 	"\x13BatchMetricsMessage\x12/\n" +
-	"\ametrics\x18\x01 \x03(\v2\x15.metric.MetricMessageR\ametrics2\xd2\x02\n" +
+	"\ametrics\x18\x01 \x03(\v2\x15.metric.MetricMessageR\ametrics\"]\n" +
+	"\x14StreamMetricsRequest\x121\n" +
+	"\x05batch\x18\x01 \x01(\v2\x1b.metric.BatchMetricsMessageR\x05batch\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\"9\n" +
+	"\tStreamAck\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"N\n" +
+	"\fWatchRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash2\xd4\x03\n" +
 	"\aMetrcic\x12?\n" +
 	"\bV1GetAll\x12\x16.google.protobuf.Empty\x1a\x1b.metric.BatchMetricsMessage\x128\n" +
 	"\x06V1Ping\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x12>\n" +
 	"\x0eV1UpdateMetric\x12\x15.metric.MetricMessage\x1a\x15.metric.MetricMessage\x12O\n" +
 	"\x13V1UpdateManyMetrics\x12\x1b.metric.BatchMetricsMessage\x1a\x1b.metric.BatchMetricsMessage\x12;\n" +
-	"\vV1GetMetric\x12\x15.metric.MetricMessage\x1a\x15.metric.MetricMessageB\x14Z\x12internal/pb/metricb\x06proto3"
+	"\vV1GetMetric\x12\x15.metric.MetricMessage\x1a\x15.metric.MetricMessage\x12F\n" +
+	"\x0fV1StreamMetrics\x12\x1c.metric.StreamMetricsRequest\x1a\x11.metric.StreamAck(\x010\x01\x128\n" +
+	"\aV1Watch\x12\x14.metric.WatchRequest\x1a\x15.metric.MetricMessage0\x01B\x14Z\x12internal/pb/metricb\x06proto3"
 
 var (
 	file_proto_metric_proto_rawDescOnce sync.Once
@@ -167,29 +583,47 @@ func file_proto_metric_proto_rawDescGZIP() []byte {
 	return file_proto_metric_proto_rawDescData
 }
 
-var file_proto_metric_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_metric_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_proto_metric_proto_goTypes = []any{
-	(*MetricMessage)(nil),       // 0: metric.MetricMessage
-	(*BatchMetricsMessage)(nil), // 1: metric.BatchMetricsMessage
-	(*emptypb.Empty)(nil),       // 2: google.protobuf.Empty
+	(*MetricMessage)(nil),        // 0: metric.MetricMessage
+	(*Sketch)(nil),               // 1: metric.Sketch
+	(*HyperLogLog)(nil),          // 2: metric.HyperLogLog
+	(*BatchMetricsMessage)(nil),  // 3: metric.BatchMetricsMessage
+	(*StreamMetricsRequest)(nil), // 4: metric.StreamMetricsRequest
+	(*StreamAck)(nil),            // 5: metric.StreamAck
+	(*WatchRequest)(nil),         // 6: metric.WatchRequest
+	nil,                          // 7: metric.MetricMessage.LabelsEntry
+	nil,                          // 8: metric.Sketch.PositiveEntry
+	nil,                          // 9: metric.Sketch.NegativeEntry
+	(*emptypb.Empty)(nil),        // 10: google.protobuf.Empty
 }
 var file_proto_metric_proto_depIdxs = []int32{
-	0, // 0: metric.BatchMetricsMessage.metrics:type_name -> metric.MetricMessage
-	2, // 1: metric.Metrcic.V1GetAll:input_type -> google.protobuf.Empty
-	2, // 2: metric.Metrcic.V1Ping:input_type -> google.protobuf.Empty
-	0, // 3: metric.Metrcic.V1UpdateMetric:input_type -> metric.MetricMessage
-	1, // 4: metric.Metrcic.V1UpdateManyMetrics:input_type -> metric.BatchMetricsMessage
-	0, // 5: metric.Metrcic.V1GetMetric:input_type -> metric.MetricMessage
-	1, // 6: metric.Metrcic.V1GetAll:output_type -> metric.BatchMetricsMessage
-	2, // 7: metric.Metrcic.V1Ping:output_type -> google.protobuf.Empty
-	0, // 8: metric.Metrcic.V1UpdateMetric:output_type -> metric.MetricMessage
-	1, // 9: metric.Metrcic.V1UpdateManyMetrics:output_type -> metric.BatchMetricsMessage
-	0, // 10: metric.Metrcic.V1GetMetric:output_type -> metric.MetricMessage
-	6, // [6:11] is the sub-list for method output_type
-	1, // [1:6] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	7,  // 0: metric.MetricMessage.labels:type_name -> metric.MetricMessage.LabelsEntry
+	1,  // 1: metric.MetricMessage.sketch:type_name -> metric.Sketch
+	2,  // 2: metric.MetricMessage.hll:type_name -> metric.HyperLogLog
+	8,  // 3: metric.Sketch.positive:type_name -> metric.Sketch.PositiveEntry
+	9,  // 4: metric.Sketch.negative:type_name -> metric.Sketch.NegativeEntry
+	0,  // 5: metric.BatchMetricsMessage.metrics:type_name -> metric.MetricMessage
+	3,  // 6: metric.StreamMetricsRequest.batch:type_name -> metric.BatchMetricsMessage
+	10, // 7: metric.Metrcic.V1GetAll:input_type -> google.protobuf.Empty
+	10, // 8: metric.Metrcic.V1Ping:input_type -> google.protobuf.Empty
+	0,  // 9: metric.Metrcic.V1UpdateMetric:input_type -> metric.MetricMessage
+	3,  // 10: metric.Metrcic.V1UpdateManyMetrics:input_type -> metric.BatchMetricsMessage
+	0,  // 11: metric.Metrcic.V1GetMetric:input_type -> metric.MetricMessage
+	4,  // 12: metric.Metrcic.V1StreamMetrics:input_type -> metric.StreamMetricsRequest
+	6,  // 13: metric.Metrcic.V1Watch:input_type -> metric.WatchRequest
+	3,  // 14: metric.Metrcic.V1GetAll:output_type -> metric.BatchMetricsMessage
+	10, // 15: metric.Metrcic.V1Ping:output_type -> google.protobuf.Empty
+	0,  // 16: metric.Metrcic.V1UpdateMetric:output_type -> metric.MetricMessage
+	3,  // 17: metric.Metrcic.V1UpdateManyMetrics:output_type -> metric.BatchMetricsMessage
+	0,  // 18: metric.Metrcic.V1GetMetric:output_type -> metric.MetricMessage
+	5,  // 19: metric.Metrcic.V1StreamMetrics:output_type -> metric.StreamAck
+	0,  // 20: metric.Metrcic.V1Watch:output_type -> metric.MetricMessage
+	14, // [14:21] is the sub-list for method output_type
+	7,  // [7:14] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_proto_metric_proto_init() }
@@ -204,7 +638,7 @@ func file_proto_metric_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_metric_proto_rawDesc), len(file_proto_metric_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -25,6 +25,8 @@ const (
 	Metrcic_V1UpdateMetric_FullMethodName      = "/metric.Metrcic/V1UpdateMetric"
 	Metrcic_V1UpdateManyMetrics_FullMethodName = "/metric.Metrcic/V1UpdateManyMetrics"
 	Metrcic_V1GetMetric_FullMethodName         = "/metric.Metrcic/V1GetMetric"
+	Metrcic_V1StreamMetrics_FullMethodName     = "/metric.Metrcic/V1StreamMetrics"
+	Metrcic_V1Watch_FullMethodName             = "/metric.Metrcic/V1Watch"
 )
 
 // MetrcicClient is the client API for Metrcic service.
@@ -36,6 +38,8 @@ type MetrcicClient interface {
 	V1UpdateMetric(ctx context.Context, in *MetricMessage, opts ...grpc.CallOption) (*MetricMessage, error)
 	V1UpdateManyMetrics(ctx context.Context, in *BatchMetricsMessage, opts ...grpc.CallOption) (*BatchMetricsMessage, error)
 	V1GetMetric(ctx context.Context, in *MetricMessage, opts ...grpc.CallOption) (*MetricMessage, error)
+	V1StreamMetrics(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamMetricsRequest, StreamAck], error)
+	V1Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MetricMessage], error)
 }
 
 type metrcicClient struct {
@@ -96,6 +100,38 @@ func (c *metrcicClient) V1GetMetric(ctx context.Context, in *MetricMessage, opts
 	return out, nil
 }
 
+func (c *metrcicClient) V1StreamMetrics(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamMetricsRequest, StreamAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Metrcic_ServiceDesc.Streams[0], Metrcic_V1StreamMetrics_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamMetricsRequest, StreamAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Metrcic_V1StreamMetricsClient = grpc.BidiStreamingClient[StreamMetricsRequest, StreamAck]
+
+func (c *metrcicClient) V1Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MetricMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Metrcic_ServiceDesc.Streams[1], Metrcic_V1Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, MetricMessage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Metrcic_V1WatchClient = grpc.ServerStreamingClient[MetricMessage]
+
 // MetrcicServer is the server API for Metrcic service.
 // All implementations must embed UnimplementedMetrcicServer
 // for forward compatibility.
@@ -105,6 +141,8 @@ type MetrcicServer interface {
 	V1UpdateMetric(context.Context, *MetricMessage) (*MetricMessage, error)
 	V1UpdateManyMetrics(context.Context, *BatchMetricsMessage) (*BatchMetricsMessage, error)
 	V1GetMetric(context.Context, *MetricMessage) (*MetricMessage, error)
+	V1StreamMetrics(grpc.BidiStreamingServer[StreamMetricsRequest, StreamAck]) error
+	V1Watch(*WatchRequest, grpc.ServerStreamingServer[MetricMessage]) error
 	mustEmbedUnimplementedMetrcicServer()
 }
 
@@ -130,6 +168,12 @@ func (UnimplementedMetrcicServer) V1UpdateManyMetrics(context.Context, *BatchMet
 func (UnimplementedMetrcicServer) V1GetMetric(context.Context, *MetricMessage) (*MetricMessage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method V1GetMetric not implemented")
 }
+func (UnimplementedMetrcicServer) V1StreamMetrics(grpc.BidiStreamingServer[StreamMetricsRequest, StreamAck]) error {
+	return status.Errorf(codes.Unimplemented, "method V1StreamMetrics not implemented")
+}
+func (UnimplementedMetrcicServer) V1Watch(*WatchRequest, grpc.ServerStreamingServer[MetricMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method V1Watch not implemented")
+}
 func (UnimplementedMetrcicServer) mustEmbedUnimplementedMetrcicServer() {}
 func (UnimplementedMetrcicServer) testEmbeddedByValue()                 {}
 
@@ -241,6 +285,24 @@ func _Metrcic_V1GetMetric_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Metrcic_V1StreamMetrics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MetrcicServer).V1StreamMetrics(&grpc.GenericServerStream[StreamMetricsRequest, StreamAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Metrcic_V1StreamMetricsServer = grpc.BidiStreamingServer[StreamMetricsRequest, StreamAck]
+
+func _Metrcic_V1Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MetrcicServer).V1Watch(m, &grpc.GenericServerStream[WatchRequest, MetricMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Metrcic_V1WatchServer = grpc.ServerStreamingServer[MetricMessage]
+
 // Metrcic_ServiceDesc is the grpc.ServiceDesc for Metrcic service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -269,6 +331,18 @@ var Metrcic_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Metrcic_V1GetMetric_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "V1StreamMetrics",
+			Handler:       _Metrcic_V1StreamMetrics_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "V1Watch",
+			Handler:       _Metrcic_V1Watch_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "proto/metric.proto",
 }
@@ -22,8 +22,10 @@ func NewRouter(metricService *service.MetricService, config *config.Config) (chi
 	updateHandlers := handlers.NewUpdateHandlers(metricService)
 	valueHandlers := handlers.NewValueHandlers(metricService)
 	commonHandlers := handlers.NewCommonHandlers(metricService)
+	queryHandlers := handlers.NewQueryHandlers(metricService)
+	watchHandlers := handlers.NewWatchHandlers(metricService)
 
-	if config.CryptoKey != nil {
+	if config.CryptoKey != nil && *config.CryptoKey != "" {
 		pKey, err := crypto.GetPrivateKey(*config.CryptoKey)
 		if err != nil {
 			return nil, err
@@ -32,6 +34,12 @@ func NewRouter(metricService *service.MetricService, config *config.Config) (chi
 		privateKey = pKey
 	}
 
+	var key string
+	if config.Key != nil {
+		key = *config.Key
+	}
+	hashMiddleware := middleware2.CryptoMiddleware{Key: key}
+
 	r := chi.NewRouter()
 
 	if config.TrustedSubnet != nil {
@@ -46,7 +54,9 @@ func NewRouter(metricService *service.MetricService, config *config.Config) (chi
 
 	r.Get("/", commonHandlers.GetMetrics)
 	r.Get("/ping", commonHandlers.GetPing)
+	r.Get("/metrics", commonHandlers.GetPrometheusMetrics)
 	r.Route("/update", func(r chi.Router) {
+		r.Use(hashMiddleware.GetCryptoMiddleware)
 		cryptoMiddleware := middleware2.CryptoRSAMiddleware{PrivateKey: privateKey}
 		r.Use(cryptoMiddleware.GetCryptoRSAMiddleware)
 		r.Use(middleware2.CompressMiddleware)
@@ -56,8 +66,14 @@ func NewRouter(metricService *service.MetricService, config *config.Config) (chi
 	})
 	r.Route("/value", func(r chi.Router) {
 		r.Post("/", valueHandlers.GetMetric)
+		r.Get("/{metricType}/{metricName}", valueHandlers.GetMetricValue)
+	})
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Get("/query_range", queryHandlers.QueryRange)
+		r.Get("/watch", watchHandlers.Watch)
 	})
 	r.Route("/updates", func(r chi.Router) {
+		r.Use(hashMiddleware.GetCryptoMiddleware)
 		cryptoMiddleware := middleware2.CryptoRSAMiddleware{PrivateKey: privateKey}
 		r.Use(cryptoMiddleware.GetCryptoRSAMiddleware)
 		r.Use(middleware2.CompressMiddleware)
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"errors"
+	errors2 "go-svc-metrics/internal/utils/errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus переводит ошибку сервиса в статус gRPC.
+// Ошибки валидации возвращаются с errdetails.BadRequest по каждому полю.
+func toStatus(err error) *status.Status {
+	switch {
+	case errors2.IsValidation(err):
+		st := status.New(codes.InvalidArgument, err.Error())
+		fieldErrors := errors2.FieldErrors(err)
+		if len(fieldErrors) == 0 {
+			return st
+		}
+
+		badRequest := &errdetails.BadRequest{}
+		for _, fieldErr := range fieldErrors {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fieldErr.Field,
+				Description: fieldErr.Err.Error(),
+			})
+		}
+		if detailed, detailsErr := st.WithDetails(badRequest); detailsErr == nil {
+			return detailed
+		}
+		return st
+	case errors.Is(err, errors2.ErrMetricNotFound):
+		return status.New(codes.NotFound, err.Error())
+	case errors.Is(err, errors2.ErrStorageUnavailable):
+		return status.New(codes.Unavailable, err.Error())
+	default:
+		return status.New(codes.Internal, err.Error())
+	}
+}
+
+func toStatusError(err error) error {
+	return toStatus(err).Err()
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		code       codes.Code
+		violations []string
+	}{
+		{
+			name: "validation errors of batch",
+			err: errors.Join(
+				&errors2.FieldError{Field: "metrics[0].id", Err: errors2.ErrEmptyMetricID},
+				errors.Join(&errors2.FieldError{Field: "metrics[2].type", Err: errors2.ErrInvalidMetricVType}),
+			),
+			code:       codes.InvalidArgument,
+			violations: []string{"metrics[0].id", "metrics[2].type"},
+		},
+		{name: "invalid metric type", err: errors2.ErrInvalidMetricVType, code: codes.InvalidArgument},
+		{name: "metric not found", err: fmt.Errorf("%w: no rows", errors2.ErrMetricNotFound), code: codes.NotFound},
+		{name: "storage unavailable", err: fmt.Errorf("%w: connection refused", errors2.ErrStorageUnavailable), code: codes.Unavailable},
+		{name: "unexpected error", err: errors.New("unexpected"), code: codes.Internal},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			st := toStatus(v.err)
+			assert.Equal(t, v.code, st.Code())
+
+			var violations []string
+			for _, detail := range st.Details() {
+				badRequest, ok := detail.(*errdetails.BadRequest)
+				require.True(t, ok)
+				for _, violation := range badRequest.GetFieldViolations() {
+					violations = append(violations, violation.GetField())
+				}
+			}
+			assert.Equal(t, v.violations, violations)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"go-svc-metrics/internal/logger"
+	pb "go-svc-metrics/internal/pb/metric"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthCheckInterval интервал проверки хранилища для grpc.health.v1.
+const healthCheckInterval = 5 * time.Second
+
+// pinger проверяет доступность хранилища.
+type pinger interface {
+	Ping() error
+}
+
+// watchHealth обновляет статус health check по результату Ping, пока ctx не отменен.
+func watchHealth(ctx context.Context, healthServer *health.Server, storage pinger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		updateHealth(healthServer, storage)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func updateHealth(healthServer *health.Server, storage pinger) {
+	servingStatus := healthpb.HealthCheckResponse_SERVING
+	if err := storage.Ping(); err != nil {
+		logger.Log.Warn("storage is unavailable", zap.Error(err))
+		servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	// Пустое имя - статус сервера в целом.
+	healthServer.SetServingStatus("", servingStatus)
+	healthServer.SetServingStatus(pb.Metrcic_ServiceDesc.ServiceName, servingStatus)
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"errors"
+	pb "go-svc-metrics/internal/pb/metric"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (f *fakePinger) Ping() error {
+	return f.err
+}
+
+func TestUpdateHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{name: "storage is available", status: healthpb.HealthCheckResponse_SERVING},
+		{name: "storage is unavailable", err: errors.New("connection refused"), status: healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			healthServer := health.NewServer()
+			updateHealth(healthServer, &fakePinger{err: v.err})
+
+			for _, service := range []string{"", pb.Metrcic_ServiceDesc.ServiceName} {
+				resp, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+				require.NoError(t, err)
+				assert.Equal(t, v.status, resp.GetStatus())
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package server
 
 import (
 	"context"
+	"errors"
 	pb "go-svc-metrics/internal/pb/metric"
+	"go-svc-metrics/internal/service"
 	"go-svc-metrics/models"
+	"io"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -15,7 +18,7 @@ func (m *MetricServer) GetAll(ctx context.Context, _ *emptypb.Empty) (*pb.BatchM
 
 	metrics, err := m.metricService.GetAllMetrics(ctx)
 	if err != nil {
-		return &metricsResponse, status.Error(codes.Unknown, err.Error())
+		return &metricsResponse, toStatusError(err)
 	}
 
 	for _, metric := range metrics {
@@ -31,7 +34,8 @@ func (m *MetricServer) GetAll(ctx context.Context, _ *emptypb.Empty) (*pb.BatchM
 func (m *MetricServer) V1Ping(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
 	err := m.metricService.Ping()
 	if err != nil {
-		return nil, status.Error(codes.Unimplemented, err.Error())
+		// Ping проверяет именно хранилище, поэтому любая ошибка - недоступность.
+		return nil, status.Error(codes.Unavailable, err.Error())
 	}
 	return &emptypb.Empty{}, nil
 }
@@ -42,7 +46,7 @@ func (m *MetricServer) V1UpdateMetric(ctx context.Context, in *pb.MetricMessage)
 
 	updatedMetrics, err := m.metricService.UpdateMetrics(ctx, []models.Metrics{metric})
 	if err != nil {
-		return nil, status.Error(codes.Unimplemented, err.Error())
+		return nil, toStatusError(err)
 	}
 
 	return updatedMetrics[0].ToProto(), nil
@@ -53,7 +57,7 @@ func (m *MetricServer) V1UpdateManyMetrics(ctx context.Context, in *pb.BatchMetr
 
 	updatedMetrics, err := m.metricService.UpdateMetrics(ctx, metrics.FromProto(in))
 	if err != nil {
-		return nil, status.Error(codes.Unimplemented, err.Error())
+		return nil, toStatusError(err)
 	}
 
 	return updatedMetrics.ToProto(), nil
@@ -64,8 +68,50 @@ func (m *MetricServer) V1GetMetric(ctx context.Context, in *pb.MetricMessage) (*
 
 	metric, err := m.metricService.GetMetric(ctx, metricReq.FromProto(in))
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, toStatusError(err)
 	}
 
 	return metric.ToProto(), nil
 }
+
+// V1StreamMetrics принимает батчи метрик в долгоживущем потоке и подтверждает каждый батч.
+// Ошибка обновления батча не закрывает поток, а возвращается в подтверждении.
+func (m *MetricServer) V1StreamMetrics(stream pb.Metrcic_V1StreamMetricsServer) error {
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var metrics models.BatchMetrics
+		ack := &pb.StreamAck{Code: int32(codes.OK)}
+		if _, err := m.metricService.UpdateMetrics(stream.Context(), metrics.FromProto(in.GetBatch())); err != nil {
+			st := toStatus(err)
+			ack.Code = int32(st.Code())
+			ack.Message = st.Message()
+		}
+
+		if err := stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+// V1Watch отправляет клиенту принятые обновления метрик, подходящих под фильтр.
+func (m *MetricServer) V1Watch(in *pb.WatchRequest, stream pb.Metrcic_V1WatchServer) error {
+	filter := service.WatchFilter{Prefix: in.GetPrefix(), MType: in.GetType()}
+	updates, err := m.metricService.Watch(stream.Context(), filter)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	for metric := range updates {
+		if err := stream.Send(metric.ToProto()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
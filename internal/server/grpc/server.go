@@ -1,12 +1,14 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"go-svc-metrics/internal/config"
 	"go-svc-metrics/internal/interceptors"
 	pb "go-svc-metrics/internal/pb/metric"
 	"go-svc-metrics/internal/service"
+	"go-svc-metrics/internal/utils/crypto"
 	"net"
 	"os"
 
@@ -14,11 +16,18 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 type App struct {
-	gRPCServer *grpc.Server
-	addrGRPC   string
+	gRPCServer    *grpc.Server
+	addrGRPC      string
+	healthServer  *health.Server
+	metricService *service.MetricService
+	healthCtx     context.Context
+	stopHealth    context.CancelFunc
 }
 
 func (a *App) Run() error {
@@ -27,6 +36,12 @@ func (a *App) Run() error {
 		return err
 	}
 
+	return a.serve(l)
+}
+
+func (a *App) serve(l net.Listener) error {
+	go watchHealth(a.healthCtx, a.healthServer, a.metricService, healthCheckInterval)
+
 	if err := a.gRPCServer.Serve(l); err != nil {
 		return err
 	}
@@ -34,44 +49,97 @@ func (a *App) Run() error {
 	return nil
 }
 
-func (a *App) Stop() {
-	a.gRPCServer.GracefulStop()
+// Stop ждет завершения текущих запросов до отмены ctx, затем закрывает соединения.
+// Потоки агентов и подписки не завершаются сами, поэтому без срока GracefulStop ждал бы их бесконечно.
+func (a *App) Stop(ctx context.Context) {
+	a.stopHealth()
+	// Пробы получают NOT_SERVING, пока сервер завершает текущие запросы.
+	a.healthServer.Shutdown()
+	// V1Watch завершается, когда закрыт канал подписки.
+	a.metricService.StopWatch()
+
+	stopped := make(chan struct{})
+	go func() {
+		a.gRPCServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.gRPCServer.Stop()
+		<-stopped
+	}
 }
 
 func NewApp(metricService *service.MetricService, cfg *config.Config) (*App, error) {
 	var serverOpts []grpc.ServerOption
 	var interceptorsOpts []grpc.UnaryServerInterceptor
+	var streamInterceptorsOpts []grpc.StreamServerInterceptor
 
+	mutualTLS := cfg.CACert != nil && *cfg.CACert != ""
+	if mutualTLS {
+		// Идентификатор агента нужен уже при логировании запроса.
+		interceptorsOpts = append(interceptorsOpts, interceptors.NewIdentityInterceptor())
+		streamInterceptorsOpts = append(streamInterceptorsOpts, interceptors.NewIdentityStreamInterceptor())
+	}
 	interceptorsOpts = append(interceptorsOpts, interceptors.LoggingInterceptor)
+	streamInterceptorsOpts = append(streamInterceptorsOpts, interceptors.LoggingStreamInterceptor)
+
+	if cfg.Cert != nil && *cfg.Cert != "" {
+		var caFilePath string
+		if mutualTLS {
+			caFilePath = *cfg.CACert
+		}
 
-	if cfg.Cert != nil {
-		tlsCredentials, err := loadTLSCredentials(*cfg.Cert, *cfg.CryptoKey)
+		tlsCredentials, err := loadTLSCredentials(*cfg.Cert, *cfg.CryptoKey, caFilePath)
 		if err != nil {
 			return nil, err
 		}
 
 		serverOpts = append(serverOpts, grpc.Creds(tlsCredentials))
+	} else if mutualTLS {
+		return nil, fmt.Errorf("ca-cert requires server cert and crypto-key")
 	}
 
-	if cfg.TrustedSubnet != nil {
+	// При mTLS агент определяется по сертификату, а не по X-Real-IP.
+	if cfg.TrustedSubnet != nil && !mutualTLS {
 		_, network, err := net.ParseCIDR(*cfg.TrustedSubnet)
 		if err != nil {
 			return nil, err
 		}
 		interceptorsOpts = append(interceptorsOpts, interceptors.NewRealIPInterceptor(network))
+		streamInterceptorsOpts = append(streamInterceptorsOpts, interceptors.NewRealIPStreamInterceptor(network))
+	}
+
+	if cfg.Key != nil && *cfg.Key != "" {
+		interceptorsOpts = append(interceptorsOpts, interceptors.NewHashInterceptor(*cfg.Key))
+		streamInterceptorsOpts = append(streamInterceptorsOpts, interceptors.NewHashStreamInterceptor(*cfg.Key))
 	}
 
 	serverOpts = append(serverOpts, grpc.ChainUnaryInterceptor(interceptorsOpts...))
+	serverOpts = append(serverOpts, grpc.ChainStreamInterceptor(streamInterceptorsOpts...))
 	gRPCServer := grpc.NewServer(serverOpts...)
 	registerMetrcicServer(gRPCServer, metricService)
 
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(gRPCServer, healthServer)
+	reflection.Register(gRPCServer)
+
+	healthCtx, stopHealth := context.WithCancel(context.Background())
 	return &App{
-		gRPCServer: gRPCServer,
-		addrGRPC:   *cfg.AddrGRPC,
+		gRPCServer:    gRPCServer,
+		addrGRPC:      *cfg.AddrGRPC,
+		healthServer:  healthServer,
+		metricService: metricService,
+		healthCtx:     healthCtx,
+		stopHealth:    stopHealth,
 	}, nil
 }
 
-func loadTLSCredentials(certFilePath, privateKeyFilePath string) (credentials.TransportCredentials, error) {
+// loadTLSCredentials загружает сертификат сервера.
+// Если задан caFilePath, сервер требует и проверяет клиентские сертификаты.
+func loadTLSCredentials(certFilePath, privateKeyFilePath, caFilePath string) (credentials.TransportCredentials, error) {
 	privateKeyPEM, err := os.ReadFile(privateKeyFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("error reading private key file: %v", err)
@@ -92,6 +160,15 @@ func loadTLSCredentials(certFilePath, privateKeyFilePath string) (credentials.Tr
 		ClientAuth:   tls.NoClientCert,
 	}
 
+	if caFilePath != "" {
+		clientCAs, err := crypto.GetCertPool(caFilePath)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
 	return credentials.NewTLS(config), nil
 }
 
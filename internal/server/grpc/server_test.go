@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"go-svc-metrics/internal/config"
+	"go-svc-metrics/internal/domain/mocks"
+	pb "go-svc-metrics/internal/pb/metric"
+	"go-svc-metrics/internal/service"
+	"go-svc-metrics/models"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestAppStopWithOpenStreams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockMetricRepo(ctrl)
+	repo.EXPECT().Ping().Return(nil).AnyTimes()
+	repo.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, metrics []models.Metrics) ([]models.Metrics, error) { return metrics, nil },
+	)
+
+	require.NoError(t, config.InitDefaultEnv())
+	cfg, err := config.InitConfig()
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	cfg.AddrGRPC = &addr
+
+	app, err := NewApp(service.NewMetricService(repo), cfg)
+	require.NoError(t, err)
+	go app.serve(listener)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := pb.NewMetrcicClient(conn)
+	watch, err := client.V1Watch(context.Background(), &pb.WatchRequest{})
+	require.NoError(t, err)
+
+	// Поток агента остается открытым после подтверждения батча.
+	stream, err := client.V1StreamMetrics(context.Background())
+	require.NoError(t, err)
+	value := 1.0
+	batch := models.BatchMetrics{{ID: "Alloc", MType: models.Gauge, Value: &value}}
+	require.NoError(t, stream.Send(&pb.StreamMetricsRequest{Batch: batch.ToProto()}))
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	stopped := make(chan struct{})
+	go func() {
+		app.Stop(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return with an open stream")
+	}
+	_, err = stream.Recv()
+	require.Error(t, err)
+	// Подписка может получить принятый батч и завершается штатно до закрытия соединений.
+	var watchErr error
+	for watchErr == nil {
+		_, watchErr = watch.Recv()
+	}
+	require.ErrorIs(t, watchErr, io.EOF)
+}
@@ -5,6 +5,7 @@ import (
 	"go-svc-metrics/internal/config"
 	"go-svc-metrics/internal/router"
 	"go-svc-metrics/internal/service"
+	"net"
 
 	"net/http"
 	_ "net/http/pprof"
@@ -23,12 +24,27 @@ func NewApp(cfg *config.Config, metricService *service.MetricService) (*App, err
 	}
 
 	server := &http.Server{Addr: *cfg.ServerAddr, Handler: r}
+	// Shutdown не прерывает активные запросы, поэтому подписки SSE закрываются отдельно.
+	server.RegisterOnShutdown(metricService.StopWatch)
 
 	return &App{cfg: cfg, metricService: metricService, server: server}, nil
 }
 
+// Run запускает сервер. Если заданы сертификат и ключ TLS, сервер принимает только HTTPS.
+// Ключ TLS задается отдельно от crypto-key: тела запросов по HTTPS расшифровываются, только если задан crypto-key.
 func (a *App) Run() error {
-	return a.server.ListenAndServe()
+	listener, err := net.Listen("tcp", a.server.Addr)
+	if err != nil {
+		return err
+	}
+	return a.serve(listener)
+}
+
+func (a *App) serve(listener net.Listener) error {
+	if a.cfg.Cert != nil && *a.cfg.Cert != "" && a.cfg.TLSKey != nil && *a.cfg.TLSKey != "" {
+		return a.server.ServeTLS(listener, *a.cfg.Cert, *a.cfg.TLSKey)
+	}
+	return a.server.Serve(listener)
 }
 
 func (a *App) Stop(ctx context.Context) error {
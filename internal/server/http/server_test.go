@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	client "go-svc-metrics/internal/agent/http"
+	"go-svc-metrics/internal/config"
+	"go-svc-metrics/internal/domain/mocks"
+	"go-svc-metrics/internal/service"
+	"go-svc-metrics/models"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate пишет самоподписанный сертификат для 127.0.0.1 и его ключ.
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "metrics"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600))
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0600))
+	return certPath, keyPath
+}
+
+func TestAppServesHTTPS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockMetricRepo(ctrl)
+	repo.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, metrics []models.Metrics) ([]models.Metrics, error) { return metrics, nil },
+	)
+
+	certPath, keyPath := writeTestCertificate(t, t.TempDir())
+	require.NoError(t, config.InitDefaultEnv())
+	cfg, err := config.InitConfig()
+	require.NoError(t, err)
+	cfg.Cert, cfg.TLSKey = &certPath, &keyPath
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	cfg.ServerAddr = &addr
+
+	app, err := NewApp(cfg, service.NewMetricService(repo))
+	require.NoError(t, err)
+	done := make(chan error)
+	go func() { done <- app.serve(listener) }()
+	defer func() {
+		require.NoError(t, app.Stop(context.Background()))
+		assert.True(t, errors.Is(<-done, http.ErrServerClosed))
+	}()
+
+	// Агент без crypto-key отправляет открытое тело, его защищает только TLS.
+	realIP := "127.0.0.1"
+	https := true
+	agent, err := client.NewClientAgent(&config.Config{
+		ServerAddr: &addr,
+		RealIP:     &realIP,
+		HTTPS:      &https,
+		CACert:     &certPath,
+	})
+	require.NoError(t, err)
+
+	value := 1.0
+	metrics := []models.Metrics{{ID: "Alloc", MType: models.Gauge, Value: &value}}
+	assert.NoError(t, agent.SendBatchMetrics(context.Background(), metrics))
+}
+
+func TestAppStopClosesWatchers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockMetricRepo(ctrl)
+
+	require.NoError(t, config.InitDefaultEnv())
+	cfg, err := config.InitConfig()
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	cfg.ServerAddr = &addr
+
+	app, err := NewApp(cfg, service.NewMetricService(repo))
+	require.NoError(t, err)
+	done := make(chan error)
+	go func() { done <- app.serve(listener) }()
+
+	resp, err := http.Get("http://" + addr + "/api/v1/watch")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Подписка SSE не должна держать Shutdown до истечения срока.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, app.Stop(ctx))
+	assert.True(t, errors.Is(<-done, http.ErrServerClosed))
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"go-svc-metrics/models"
+	"strings"
+	"sync"
+)
+
+// watchBufferSize размер буфера подписчика. Если подписчик не успевает читать,
+// новые обновления для него пропускаются.
+const watchBufferSize = 256
+
+// WatchFilter фильтр подписки на обновления метрик.
+type WatchFilter struct {
+	Prefix string
+	MType  string
+}
+
+// Match проверяет, что метрика подходит под фильтр.
+func (f WatchFilter) Match(metric models.Metrics) bool {
+	if f.MType != "" && metric.MType != f.MType {
+		return false
+	}
+	return strings.HasPrefix(metric.ID, f.Prefix)
+}
+
+type subscriber struct {
+	ch     chan models.Metrics
+	filter WatchFilter
+}
+
+// Hub рассылает принятые обновления метрик подписчикам.
+type Hub struct {
+	mutex       sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+// NewHub создает Hub без подписчиков.
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[*subscriber]struct{})}
+}
+
+// Subscribe подписывает на обновления метрик, подходящих под фильтр.
+// Функция отписки закрывает канал. После Close канал возвращается уже закрытым.
+func (h *Hub) Subscribe(filter WatchFilter) (<-chan models.Metrics, func()) {
+	sub := &subscriber{ch: make(chan models.Metrics, watchBufferSize), filter: filter}
+
+	h.mutex.Lock()
+	if h.closed {
+		close(sub.ch)
+	} else {
+		h.subscribers[sub] = struct{}{}
+	}
+	h.mutex.Unlock()
+
+	return sub.ch, func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		// Канал уже закрыт, если подписчик удален при Close или повторной отписке.
+		if _, ok := h.subscribers[sub]; ok {
+			delete(h.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Close закрывает каналы всех подписчиков и отклоняет новые подписки.
+// Подписки не завершаются сами, поэтому без Close они держали бы остановку серверов.
+func (h *Hub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// Publish рассылает метрики подписчикам без блокировки.
+func (h *Hub) Publish(metrics ...models.Metrics) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for sub := range h.subscribers {
+		for _, metric := range metrics {
+			if !sub.filter.Match(metric) {
+				continue
+			}
+			select {
+			case sub.ch <- metric.Clone():
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"math"
+	"time"
+)
+
+// Функции агрегации значений внутри интервала step.
+const (
+	AggregationAvg      = "avg"
+	AggregationMin      = "min"
+	AggregationMax      = "max"
+	AggregationLast     = "last"
+	AggregationSum      = "sum"
+	AggregationRate     = "rate"
+	AggregationIncrease = "increase"
+)
+
+// maxRangePoints ограничивает количество интервалов в одном запросе.
+const maxRangePoints = 11000
+
+// RangeQuery параметры запроса значений метрики за период [Start, End).
+type RangeQuery struct {
+	Metric      models.Metrics
+	Start       time.Time
+	End         time.Time
+	Step        time.Duration
+	Aggregation string
+}
+
+// QueryRange возвращает значения метрики за период, сгруппированные по интервалам step.
+// Временная метка точки - начало интервала, интервалы без данных пропускаются.
+func (m *MetricService) QueryRange(ctx context.Context, query RangeQuery) ([]models.Sample, error) {
+	aggregation, err := checkRangeQuery(&query)
+	if err != nil {
+		return nil, err
+	}
+
+	from := query.Start
+	if query.Metric.MType == models.Counter {
+		// Для counter нужно предыдущее значение, чтобы посчитать прирост в первом интервале.
+		from = from.Add(-query.Step)
+	}
+
+	samples, err := m.metricRepo.GetMetricHistory(ctx, query.Metric, from, query.End)
+	if err != nil {
+		return nil, err
+	}
+
+	switch aggregation {
+	case AggregationRate, AggregationIncrease:
+		return aggregateCounter(samples, query, aggregation == AggregationRate), nil
+	default:
+		return aggregateGauge(samples, query, aggregation), nil
+	}
+}
+
+// DefaultAggregation возвращает агрегацию по умолчанию для типа метрики.
+func DefaultAggregation(metricType string) string {
+	switch metricType {
+	case models.Counter:
+		return AggregationRate
+	case models.Gauge:
+		return AggregationAvg
+	}
+	return ""
+}
+
+func checkRangeQuery(query *RangeQuery) (string, error) {
+	if query.Step <= 0 || query.End.Before(query.Start) {
+		return "", errors2.ErrInvalidQueryRange
+	}
+	if query.End.Sub(query.Start)/query.Step >= maxRangePoints {
+		return "", fmt.Errorf("%w: too many points, increase step", errors2.ErrInvalidQueryRange)
+	}
+
+	if query.Aggregation == "" {
+		query.Aggregation = DefaultAggregation(query.Metric.MType)
+	}
+
+	switch query.Metric.MType {
+	case models.Gauge:
+		switch query.Aggregation {
+		case AggregationAvg, AggregationMin, AggregationMax, AggregationLast, AggregationSum:
+			return query.Aggregation, nil
+		}
+	case models.Counter:
+		switch query.Aggregation {
+		case AggregationRate, AggregationIncrease:
+			return query.Aggregation, nil
+		}
+	default:
+		return "", errors2.ErrInvalidMetricVType
+	}
+	return "", errors2.ErrInvalidAggregation
+}
+
+// bucketIndex возвращает номер интервала для момента ts.
+// Конец периода не включается, иначе точка в End открывает лишний неполный интервал.
+func bucketIndex(query RangeQuery, ts time.Time) (int, bool) {
+	if ts.Before(query.Start) || !ts.Before(query.End) {
+		return 0, false
+	}
+	return int(ts.Sub(query.Start) / query.Step), true
+}
+
+func aggregateGauge(samples []models.Sample, query RangeQuery, aggregation string) []models.Sample {
+	result := make([]models.Sample, 0)
+	var count int
+	var bucket int
+
+	for _, sample := range samples {
+		index, ok := bucketIndex(query, sample.Timestamp)
+		if !ok {
+			continue
+		}
+
+		if len(result) == 0 || index != bucket {
+			if len(result) > 0 && aggregation == AggregationAvg {
+				result[len(result)-1].Value /= float64(count)
+			}
+			bucket, count = index, 0
+			result = append(result, models.Sample{
+				Timestamp: query.Start.Add(time.Duration(index) * query.Step),
+				Value:     sample.Value,
+			})
+			if aggregation == AggregationAvg || aggregation == AggregationSum {
+				result[len(result)-1].Value = 0
+			}
+		}
+
+		count++
+		point := &result[len(result)-1]
+		switch aggregation {
+		case AggregationAvg, AggregationSum:
+			point.Value += sample.Value
+		case AggregationMin:
+			point.Value = math.Min(point.Value, sample.Value)
+		case AggregationMax:
+			point.Value = math.Max(point.Value, sample.Value)
+		case AggregationLast:
+			point.Value = sample.Value
+		}
+	}
+
+	if len(result) > 0 && aggregation == AggregationAvg {
+		result[len(result)-1].Value /= float64(count)
+	}
+	return result
+}
+
+// aggregateCounter считает прирост counter в каждом интервале.
+// Уменьшение значения считается сбросом счетчика.
+func aggregateCounter(samples []models.Sample, query RangeQuery, rate bool) []models.Sample {
+	result := make([]models.Sample, 0)
+	var bucket int
+
+	for i := 1; i < len(samples); i++ {
+		index, ok := bucketIndex(query, samples[i].Timestamp)
+		if !ok {
+			continue
+		}
+
+		increase := samples[i].Value - samples[i-1].Value
+		if increase < 0 {
+			increase = samples[i].Value
+		}
+
+		if len(result) == 0 || index != bucket {
+			bucket = index
+			result = append(result, models.Sample{Timestamp: query.Start.Add(time.Duration(index) * query.Step)})
+		}
+		result[len(result)-1].Value += increase
+	}
+
+	if rate {
+		for i := range result {
+			result[i].Value /= query.Step.Seconds()
+		}
+	}
+	return result
+}
@@ -3,23 +3,31 @@ package service
 
 import (
 	"context"
+	"errors"
 	"go-svc-metrics/internal/domain"
+	"go-svc-metrics/internal/logger"
+	"go-svc-metrics/internal/sketch"
 	errors2 "go-svc-metrics/internal/utils/errors"
 	"go-svc-metrics/models"
 	"strconv"
+	"time"
+
+	"go.uber.org/zap"
 )
 
 // MetricService хранит доступ репозиторию
 type MetricService struct {
 	metricRepo domain.MetricRepo
+	hub        *Hub
 }
 
 // NewMetricService возвращает MetricService
 func NewMetricService(metricRepo domain.MetricRepo) *MetricService {
-	return &MetricService{metricRepo: metricRepo}
+	return &MetricService{metricRepo: metricRepo, hub: NewHub()}
 }
 
 // UpdateMetric обновляет метрику в репозитории и проверяет передаваемые данные.
+// Для set значение из пути добавляется как элемент множества.
 func (m *MetricService) UpdateMetric(ctx context.Context, metricType, metricName, metricValue string) error {
 	metric := models.Metrics{
 		ID:    metricName,
@@ -33,7 +41,7 @@ func (m *MetricService) UpdateMetric(ctx context.Context, metricType, metricName
 		}
 		value := int64(metricValue)
 		metric.Delta = &value
-		_, err = m.metricRepo.UpdateMetrics(ctx, []models.Metrics{metric})
+		_, err = m.UpdateMetrics(ctx, []models.Metrics{metric})
 		if err != nil {
 			return errors2.ErrInvalidCounterOperation
 		}
@@ -43,10 +51,15 @@ func (m *MetricService) UpdateMetric(ctx context.Context, metricType, metricName
 			return errors2.ErrInvalidMetricValue
 		}
 		metric.Value = &metricValue
-		_, err = m.metricRepo.UpdateMetrics(ctx, []models.Metrics{metric})
+		_, err = m.UpdateMetrics(ctx, []models.Metrics{metric})
 		if err != nil {
 			return errors2.ErrInvalidCGaugeOperation
 		}
+	case models.Set:
+		metric.Members = []string{metricValue}
+		if _, err := m.UpdateMetrics(ctx, []models.Metrics{metric}); err != nil {
+			return err
+		}
 	default:
 		return errors2.ErrInvalidMetricVType
 	}
@@ -54,8 +67,89 @@ func (m *MetricService) UpdateMetric(ctx context.Context, metricType, metricName
 }
 
 // UpdateMetrics обновляет батч метрик в репозитории.
+// Если хотя бы одна метрика не проходит проверку, батч отклоняется целиком с *errors.BatchError.
+// Принятые обновления рассылаются подписчикам Watch.
 func (m *MetricService) UpdateMetrics(ctx context.Context, metrics models.BatchMetrics) (models.BatchMetrics, error) {
-	return m.metricRepo.UpdateMetrics(ctx, metrics)
+	if _, batchErr := validateMetrics(metrics); batchErr != nil {
+		return nil, batchErr
+	}
+	return m.updateMetrics(ctx, metrics)
+}
+
+// UpdateValidMetrics обновляет только метрики батча, прошедшие проверку.
+// Возвращает обновленные метрики и ошибки отклоненных метрик по индексам.
+func (m *MetricService) UpdateValidMetrics(ctx context.Context, metrics models.BatchMetrics) (models.BatchMetrics, *errors2.BatchError, error) {
+	valid, batchErr := validateMetrics(metrics)
+	if len(valid) == 0 {
+		return valid, batchErr, nil
+	}
+
+	updatedMetrics, err := m.updateMetrics(ctx, valid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updatedMetrics, batchErr, nil
+}
+
+func (m *MetricService) updateMetrics(ctx context.Context, metrics models.BatchMetrics) (models.BatchMetrics, error) {
+	metrics, err := prepareMetrics(metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedMetrics, err := m.metricRepo.UpdateMetrics(ctx, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	m.hub.Publish(updatedMetrics...)
+	return updatedMetrics, nil
+}
+
+// prepareMetrics приводит метрики к виду, в котором они хранятся в репозитории:
+// значения set добавляются в HyperLogLog, сами значения не сохраняются.
+func prepareMetrics(metrics models.BatchMetrics) (models.BatchMetrics, error) {
+	prepared := make(models.BatchMetrics, 0, len(metrics))
+	for _, metric := range metrics {
+		if metric.MType == models.Set && len(metric.Members) > 0 {
+			hll, err := sketch.NewHyperLogLog(sketch.DefaultPrecision)
+			if err != nil {
+				return nil, err
+			}
+			for _, member := range metric.Members {
+				hll.Add(member)
+			}
+			if metric.HLL != nil {
+				if err = hll.Merge(metric.HLL); err != nil {
+					return nil, err
+				}
+			}
+			metric.HLL = hll
+			metric.Members = nil
+		}
+		prepared = append(prepared, metric)
+	}
+	return prepared, nil
+}
+
+// Watch возвращает канал обновлений метрик, подходящих под фильтр.
+// Канал закрывается после отмены ctx или StopWatch.
+func (m *MetricService) Watch(ctx context.Context, filter WatchFilter) (<-chan models.Metrics, error) {
+	if filter.MType != "" && !models.IsValidType(filter.MType) {
+		return nil, errors2.ErrInvalidMetricVType
+	}
+
+	updates, unsubscribe := m.hub.Subscribe(filter)
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+	return updates, nil
+}
+
+// StopWatch закрывает все подписки на обновления перед остановкой серверов.
+func (m *MetricService) StopWatch() {
+	m.hub.Close()
 }
 
 // GetAllMetrics возращает все метрики из репозитория.
@@ -64,12 +158,14 @@ func (m *MetricService) GetAllMetrics(ctx context.Context) (models.BatchMetrics,
 }
 
 // GetMetricValue возвращает значение метрики.
-func (m *MetricService) GetMetricValue(ctx context.Context, metricType, metricName string) (string, error) {
+// Для histogram и summary возвращается количество наблюдений, для set - оценка количества уникальных значений.
+// labels выбирают серию метрики с метками.
+func (m *MetricService) GetMetricValue(ctx context.Context, metricType, metricName string, labels models.Labels) (string, error) {
 	var value string
-	if metricType != models.Counter && metricType != models.Gauge {
+	if !models.IsValidType(metricType) {
 		return value, errors2.ErrInvalidMetricVType
 	}
-	metric, err := m.metricRepo.GetMetric(ctx, models.Metrics{MType: metricType, ID: metricName})
+	metric, err := m.metricRepo.GetMetric(ctx, models.Metrics{MType: metricType, ID: metricName, Labels: labels})
 	if err != nil {
 		return value, err
 	}
@@ -79,12 +175,72 @@ func (m *MetricService) GetMetricValue(ctx context.Context, metricType, metricNa
 		value = strconv.Itoa(int(*metric.Delta))
 	case models.Gauge:
 		value = strconv.FormatFloat(*metric.Value, 'f', -1, 64)
+	case models.Histogram:
+		value = strconv.FormatUint(*metric.Count, 10)
+	case models.Summary:
+		value = strconv.FormatUint(metric.Sketch.Count(), 10)
+	case models.Set:
+		value = strconv.FormatUint(metric.Cardinality(), 10)
 	}
 	return value, nil
 }
 
+// GetMetricQuantile возвращает оценку квантиля q метрики с распределением.
+func (m *MetricService) GetMetricQuantile(ctx context.Context, metricType, metricName string, labels models.Labels, q float64) (string, error) {
+	if metricType != models.Histogram && metricType != models.Summary {
+		return "", errors2.ErrInvalidQuantile
+	}
+	metric, err := m.metricRepo.GetMetric(ctx, models.Metrics{MType: metricType, ID: metricName, Labels: labels})
+	if err != nil {
+		return "", err
+	}
+
+	quantile, err := metric.Quantile(q)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(quantile, 'f', -1, 64), nil
+}
+
+// GetMetric возвращает метрику из репозитория.
+// Для set в Value записывается оценка количества уникальных значений.
 func (m *MetricService) GetMetric(ctx context.Context, metric models.Metrics) (models.Metrics, error) {
-	return m.metricRepo.GetMetric(ctx, metric)
+	if err := validateMetricRequest(metric); err != nil {
+		return models.Metrics{}, err
+	}
+
+	metric, err := m.metricRepo.GetMetric(ctx, metric)
+	if err != nil {
+		return metric, err
+	}
+	if metric.MType == models.Set {
+		cardinality := float64(metric.Cardinality())
+		metric.Value = &cardinality
+	}
+	return metric, nil
+}
+
+// GetMetricHistory возвращает сохраненные значения метрики за период.
+func (m *MetricService) GetMetricHistory(ctx context.Context, metric models.Metrics, from, to time.Time) ([]models.Sample, error) {
+	return m.metricRepo.GetMetricHistory(ctx, metric, from, to)
+}
+
+// ExpireHistoryByInterval каждые interval удаляет устаревшие значения истории до отмены ctx.
+// Ошибка очистки логируется, следующая попытка будет на следующем тике.
+func (m *MetricService) ExpireHistoryByInterval(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := m.metricRepo.ExpireHistory(ctx, now); err != nil {
+				logger.Log.Warn("cannot expire metrics history", zap.Error(err))
+			}
+		}
+	}
 }
 
 // Ping проверяет коннкт к БД.
@@ -97,7 +253,38 @@ func (m *MetricService) Close() error {
 	return m.metricRepo.Close()
 }
 
-// DumpMetricsByInterval сохраняет данные в репозиторий.
+// dumpRestartDelay пауза перед перезапуском сохранения после паники.
+var dumpRestartDelay = time.Second
+
+// errDumpPanicked сохранение завершилось паникой после отмены ctx.
+var errDumpPanicked = errors.New("metrics dump panicked")
+
+// DumpMetricsByInterval периодически сохраняет данные репозитория до отмены ctx.
+// После паники сохранение перезапускается; если ctx уже отменен, перезапуск сразу сохраняет последний снимок.
 func (m *MetricService) DumpMetricsByInterval(ctx context.Context) error {
-	return m.metricRepo.DumpMetricsByInterval(ctx)
+	for {
+		panicked, err := m.dumpMetricsByInterval(ctx)
+		if !panicked {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			// Последний снимок пробуем сохранить еще раз.
+			if panicked, err = m.dumpMetricsByInterval(ctx); panicked {
+				return errDumpPanicked
+			}
+			return err
+		case <-time.After(dumpRestartDelay):
+		}
+	}
+}
+
+func (m *MetricService) dumpMetricsByInterval(ctx context.Context) (panicked bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Log.Error("metrics dump panicked, restarting", zap.Any("panic", r), zap.Stack("stack"))
+			panicked = true
+		}
+	}()
+	return false, m.metricRepo.DumpMetricsByInterval(ctx)
 }
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"go-svc-metrics/internal/domain/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpMetricsByIntervalRestartsAfterPanic(t *testing.T) {
+	dumpRestartDelay = time.Millisecond
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockMetricRepo(ctrl)
+	gomock.InOrder(
+		repo.EXPECT().DumpMetricsByInterval(gomock.Any()).Do(func(context.Context) { panic("disk is gone") }),
+		repo.EXPECT().DumpMetricsByInterval(gomock.Any()).Return(nil),
+	)
+
+	assert.NoError(t, NewMetricService(repo).DumpMetricsByInterval(context.Background()))
+}
+
+func TestDumpMetricsByIntervalPanicsOnShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockMetricRepo(ctrl)
+	repo.EXPECT().DumpMetricsByInterval(gomock.Any()).Do(func(context.Context) { panic("disk is gone") }).Times(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, NewMetricService(repo).DumpMetricsByInterval(ctx), errDumpPanicked)
+}
+
+func TestHubClose(t *testing.T) {
+	hub := NewHub()
+	updates, unsubscribe := hub.Subscribe(WatchFilter{})
+	hub.Close()
+
+	_, ok := <-updates
+	assert.False(t, ok)
+	// Отписка после Close не закрывает канал повторно.
+	unsubscribe()
+
+	updates, _ = hub.Subscribe(WatchFilter{})
+	_, ok = <-updates
+	assert.False(t, ok)
+}
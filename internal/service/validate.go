@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"go-svc-metrics/models"
+	"math"
+)
+
+// validateMetric проверяет метрику из запроса на обновление.
+// field - префикс имен полей в ошибках, например metrics[2].
+func validateMetric(metric models.Metrics, field string) error {
+	var errs []error
+	if metric.ID == "" {
+		errs = append(errs, &errors2.FieldError{Field: field + "id", Err: errors2.ErrEmptyMetricID})
+	}
+
+	switch metric.MType {
+	case models.Counter:
+		if metric.Delta == nil {
+			errs = append(errs, &errors2.FieldError{Field: field + "delta", Err: errors2.ErrMissingMetricValue})
+		}
+	case models.Gauge:
+		if metric.Value == nil {
+			errs = append(errs, &errors2.FieldError{Field: field + "value", Err: errors2.ErrMissingMetricValue})
+		}
+	case models.Histogram:
+		errs = append(errs, validateHistogram(metric, field)...)
+	case models.Summary:
+		if metric.Sketch == nil {
+			errs = append(errs, &errors2.FieldError{Field: field + "sketch", Err: errors2.ErrMissingMetricValue})
+		} else if err := metric.Sketch.Validate(); err != nil {
+			errs = append(errs, &errors2.FieldError{Field: field + "sketch", Err: err})
+		}
+	case models.Set:
+		if len(metric.Members) == 0 && metric.HLL == nil {
+			errs = append(errs, &errors2.FieldError{Field: field + "members", Err: errors2.ErrMissingMetricValue})
+		}
+		if metric.HLL != nil {
+			if err := metric.HLL.Validate(); err != nil {
+				errs = append(errs, &errors2.FieldError{Field: field + "hll", Err: err})
+			}
+		}
+	default:
+		errs = append(errs, &errors2.FieldError{Field: field + "type", Err: errors2.ErrInvalidMetricVType})
+	}
+	return errors.Join(errs...)
+}
+
+// validateMetrics проверяет все метрики батча и возвращает корректные метрики
+// и ошибки остальных по индексам.
+func validateMetrics(metrics models.BatchMetrics) (models.BatchMetrics, *errors2.BatchError) {
+	valid := make(models.BatchMetrics, 0, len(metrics))
+	var batchErr *errors2.BatchError
+	for i, metric := range metrics {
+		if err := validateMetric(metric, fmt.Sprintf("metrics[%d].", i)); err != nil {
+			if batchErr == nil {
+				batchErr = &errors2.BatchError{}
+			}
+			batchErr.Items = append(batchErr.Items, &errors2.ItemError{Index: i, Err: err})
+			continue
+		}
+		valid = append(valid, metric)
+	}
+	return valid, batchErr
+}
+
+// validateMetricRequest проверяет запрос значения метрики.
+func validateMetricRequest(metric models.Metrics) error {
+	var errs []error
+	if metric.ID == "" {
+		errs = append(errs, &errors2.FieldError{Field: "id", Err: errors2.ErrEmptyMetricID})
+	}
+	if !models.IsValidType(metric.MType) {
+		errs = append(errs, &errors2.FieldError{Field: "type", Err: errors2.ErrInvalidMetricVType})
+	}
+	return errors.Join(errs...)
+}
+
+// validateHistogram проверяет границы корзин и согласованность счетчиков гистограммы.
+func validateHistogram(metric models.Metrics, field string) []error {
+	var errs []error
+	for i, bound := range metric.Buckets {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) || (i > 0 && bound <= metric.Buckets[i-1]) {
+			errs = append(errs, &errors2.FieldError{Field: field + "buckets", Err: errors2.ErrInvalidMetricValue})
+			break
+		}
+	}
+
+	var total uint64
+	for _, count := range metric.Counts {
+		total += count
+	}
+	switch {
+	case metric.Counts == nil:
+		errs = append(errs, &errors2.FieldError{Field: field + "counts", Err: errors2.ErrMissingMetricValue})
+	case len(metric.Counts) != len(metric.Buckets)+1:
+		errs = append(errs, &errors2.FieldError{Field: field + "counts", Err: errors2.ErrBucketsMismatch})
+	}
+
+	if metric.Sum == nil || math.IsNaN(*metric.Sum) {
+		errs = append(errs, &errors2.FieldError{Field: field + "sum", Err: errors2.ErrMissingMetricValue})
+	}
+	switch {
+	case metric.Count == nil:
+		errs = append(errs, &errors2.FieldError{Field: field + "count", Err: errors2.ErrMissingMetricValue})
+	case *metric.Count != total:
+		errs = append(errs, &errors2.FieldError{Field: field + "count", Err: errors2.ErrInvalidMetricValue})
+	}
+	return errs
+}
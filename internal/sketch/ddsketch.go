@@ -0,0 +1,217 @@
+// Модуль sketch реализует сливаемые скетчи: DDSketch для квантилей
+// и HyperLogLog для количества уникальных значений.
+//
+// DDSketch хранит квантили с относительной точностью. Значение x попадает в корзину ceil(log_gamma(|x|)), где gamma = (1+alpha)/(1-alpha).
+// Оценка квантиля отличается от точного значения не более чем на alpha относительно,
+// а слияние скетчей с одинаковой точностью сводится к сложению счетчиков корзин.
+package sketch
+
+import (
+	"fmt"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultRelativeAccuracy относительная точность скетча по умолчанию.
+	DefaultRelativeAccuracy = 0.01
+	// MinRelativeAccuracy минимальная точность, при которой индексы корзин помещаются в int32.
+	MinRelativeAccuracy = 1e-4
+
+	// minIndexableValue значения меньше по модулю считаются нулем.
+	minIndexableValue = 1e-9
+)
+
+// DDSketch скетч квантилей. Positive и Negative - счетчики корзин по индексу.
+type DDSketch struct {
+	RelativeAccuracy float64          `json:"alpha"`
+	Positive         map[int32]uint64 `json:"positive,omitempty"`
+	Negative         map[int32]uint64 `json:"negative,omitempty"`
+	ZeroCount        uint64           `json:"zero_count,omitempty"`
+	Sum              float64          `json:"sum"`
+	Min              float64          `json:"min"`
+	Max              float64          `json:"max"`
+}
+
+// New создает пустой скетч с относительной точностью alpha.
+func New(alpha float64) (*DDSketch, error) {
+	if err := checkRelativeAccuracy(alpha); err != nil {
+		return nil, err
+	}
+	return &DDSketch{
+		RelativeAccuracy: alpha,
+		Positive:         make(map[int32]uint64),
+		Negative:         make(map[int32]uint64),
+	}, nil
+}
+
+// Add добавляет наблюдение. NaN игнорируется.
+func (s *DDSketch) Add(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+
+	if s.Count() == 0 {
+		s.Min, s.Max = value, value
+	} else {
+		s.Min = math.Min(s.Min, value)
+		s.Max = math.Max(s.Max, value)
+	}
+	s.Sum += value
+
+	switch {
+	case value > minIndexableValue:
+		if s.Positive == nil {
+			s.Positive = make(map[int32]uint64)
+		}
+		s.Positive[s.index(value)]++
+	case value < -minIndexableValue:
+		if s.Negative == nil {
+			s.Negative = make(map[int32]uint64)
+		}
+		s.Negative[s.index(-value)]++
+	default:
+		s.ZeroCount++
+	}
+}
+
+// Count возвращает количество наблюдений.
+func (s *DDSketch) Count() uint64 {
+	count := s.ZeroCount
+	for _, c := range s.Positive {
+		count += c
+	}
+	for _, c := range s.Negative {
+		count += c
+	}
+	return count
+}
+
+// Merge добавляет в скетч наблюдения other. Точность скетчей должна совпадать.
+func (s *DDSketch) Merge(other *DDSketch) error {
+	if s.RelativeAccuracy != other.RelativeAccuracy {
+		return errors2.ErrSketchMismatch
+	}
+	otherCount := other.Count()
+	if otherCount == 0 {
+		return nil
+	}
+
+	if s.Count() == 0 {
+		s.Min, s.Max = other.Min, other.Max
+	} else {
+		s.Min = math.Min(s.Min, other.Min)
+		s.Max = math.Max(s.Max, other.Max)
+	}
+	s.Sum += other.Sum
+	s.ZeroCount += other.ZeroCount
+	s.Positive = mergeBins(s.Positive, other.Positive)
+	s.Negative = mergeBins(s.Negative, other.Negative)
+	return nil
+}
+
+// Quantile возвращает оценку квантиля q. Для пустого скетча возвращается NaN.
+func (s *DDSketch) Quantile(q float64) (float64, error) {
+	if math.IsNaN(q) || q < 0 || q > 1 {
+		return 0, errors2.ErrInvalidQuantile
+	}
+	count := s.Count()
+	if count == 0 {
+		return math.NaN(), nil
+	}
+
+	rank := q * float64(count-1)
+	negativeCount := count - s.ZeroCount - binsCount(s.Positive)
+
+	var value float64
+	switch {
+	case rank < float64(negativeCount):
+		// Среди отрицательных значений большему индексу соответствует меньшее значение.
+		value = -s.value(keyAtRank(s.Negative, float64(negativeCount-1)-rank))
+	case rank < float64(negativeCount+s.ZeroCount):
+		value = 0
+	default:
+		value = s.value(keyAtRank(s.Positive, rank-float64(negativeCount+s.ZeroCount)))
+	}
+	return math.Max(s.Min, math.Min(s.Max, value)), nil
+}
+
+// Clone возвращает копию скетча.
+func (s *DDSketch) Clone() *DDSketch {
+	clone := *s
+	clone.Positive = mergeBins(nil, s.Positive)
+	clone.Negative = mergeBins(nil, s.Negative)
+	return &clone
+}
+
+// Validate проверяет скетч, полученный от клиента.
+func (s *DDSketch) Validate() error {
+	if err := checkRelativeAccuracy(s.RelativeAccuracy); err != nil {
+		return err
+	}
+	if math.IsNaN(s.Sum) || math.IsInf(s.Sum, 0) {
+		return fmt.Errorf("%w: sum", errors2.ErrInvalidMetricValue)
+	}
+	if s.Count() > 0 && !(s.Min <= s.Max) {
+		return fmt.Errorf("%w: min is greater than max", errors2.ErrInvalidMetricValue)
+	}
+	return nil
+}
+
+func checkRelativeAccuracy(alpha float64) error {
+	if !(alpha >= MinRelativeAccuracy && alpha < 1) {
+		return fmt.Errorf("%w: relative accuracy must be in [%g, 1)", errors2.ErrInvalidMetricValue, MinRelativeAccuracy)
+	}
+	return nil
+}
+
+func (s *DDSketch) gamma() float64 {
+	return (1 + s.RelativeAccuracy) / (1 - s.RelativeAccuracy)
+}
+
+func (s *DDSketch) index(value float64) int32 {
+	return int32(math.Ceil(math.Log(value) / math.Log(s.gamma())))
+}
+
+// value возвращает представителя корзины: середину интервала (gamma^(i-1), gamma^i] в относительной мере.
+func (s *DDSketch) value(index int32) float64 {
+	gamma := s.gamma()
+	return 2 * math.Pow(gamma, float64(index)) / (gamma + 1)
+}
+
+func mergeBins(dst, src map[int32]uint64) map[int32]uint64 {
+	if dst == nil {
+		dst = make(map[int32]uint64, len(src))
+	}
+	for index, count := range src {
+		dst[index] += count
+	}
+	return dst
+}
+
+func binsCount(bins map[int32]uint64) uint64 {
+	var count uint64
+	for _, c := range bins {
+		count += c
+	}
+	return count
+}
+
+// keyAtRank возвращает индекс корзины, в которую попадает наблюдение с рангом rank.
+func keyAtRank(bins map[int32]uint64, rank float64) int32 {
+	keys := make([]int32, 0, len(bins))
+	for index := range bins {
+		keys = append(keys, index)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var cumulative uint64
+	for _, index := range keys {
+		cumulative += bins[index]
+		if float64(cumulative) > rank {
+			return index
+		}
+	}
+	return keys[len(keys)-1]
+}
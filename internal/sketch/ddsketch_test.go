@@ -0,0 +1,86 @@
+package sketch
+
+import (
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exactQuantile(sorted []float64, q float64) float64 {
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+func TestDDSketchQuantile(t *testing.T) {
+	s, err := New(DefaultRelativeAccuracy)
+	require.NoError(t, err)
+
+	values := make([]float64, 0, 1000)
+	for i := 1; i <= 1000; i++ {
+		value := float64(i * i)
+		values = append(values, value)
+		s.Add(value)
+	}
+	sort.Float64s(values)
+
+	assert.Equal(t, uint64(1000), s.Count())
+	for _, q := range []float64{0, 0.5, 0.9, 0.99, 1} {
+		got, err := s.Quantile(q)
+		require.NoError(t, err)
+		want := exactQuantile(values, q)
+		assert.InDelta(t, want, got, want*DefaultRelativeAccuracy, "q=%v", q)
+	}
+}
+
+func TestDDSketchMerge(t *testing.T) {
+	whole, _ := New(DefaultRelativeAccuracy)
+	first, _ := New(DefaultRelativeAccuracy)
+	second, _ := New(DefaultRelativeAccuracy)
+
+	values := make([]float64, 0, 201)
+	for i := -50; i <= 150; i++ {
+		value := float64(i) / 10
+		values = append(values, value)
+		whole.Add(value)
+		if i%2 == 0 {
+			first.Add(value)
+		} else {
+			second.Add(value)
+		}
+	}
+	sort.Float64s(values)
+
+	merged := first.Clone()
+	require.NoError(t, merged.Merge(second))
+	assert.Equal(t, whole.Count(), merged.Count())
+	assert.InDelta(t, whole.Sum, merged.Sum, 1e-9)
+	assert.Equal(t, whole.Min, merged.Min)
+	assert.Equal(t, whole.Max, merged.Max)
+	assert.Equal(t, uint64(101), first.Count(), "clone must not share bins")
+
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.9} {
+		got, err := merged.Quantile(q)
+		require.NoError(t, err)
+		want := exactQuantile(values, q)
+		assert.InDelta(t, want, got, math.Abs(want)*DefaultRelativeAccuracy+1e-9, "q=%v", q)
+	}
+
+	other, _ := New(0.05)
+	assert.ErrorIs(t, merged.Merge(other), errors2.ErrSketchMismatch)
+}
+
+func TestDDSketchEmpty(t *testing.T) {
+	s, _ := New(DefaultRelativeAccuracy)
+	got, err := s.Quantile(0.5)
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(got))
+
+	_, err = s.Quantile(-1)
+	assert.ErrorIs(t, err, errors2.ErrInvalidQuantile)
+
+	_, err = New(0)
+	assert.ErrorIs(t, err, errors2.ErrInvalidMetricValue)
+}
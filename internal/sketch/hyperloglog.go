@@ -0,0 +1,114 @@
+package sketch
+
+import (
+	"fmt"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+const (
+	// DefaultPrecision точность HyperLogLog по умолчанию: 2^14 регистров, стандартная ошибка около 0.8%.
+	DefaultPrecision = 14
+
+	minPrecision = 4
+	maxPrecision = 18
+)
+
+// HyperLogLog скетч для оценки количества уникальных значений.
+// Регистр хранит максимальный ранг первой единицы хеша среди значений, попавших в регистр.
+type HyperLogLog struct {
+	Precision uint8  `json:"p"`
+	Registers []byte `json:"registers"`
+}
+
+// NewHyperLogLog создает пустой скетч с 2^precision регистрами.
+func NewHyperLogLog(precision uint8) (*HyperLogLog, error) {
+	if precision < minPrecision || precision > maxPrecision {
+		return nil, fmt.Errorf("%w: precision must be in [%d, %d]", errors2.ErrInvalidMetricValue, minPrecision, maxPrecision)
+	}
+	return &HyperLogLog{Precision: precision, Registers: make([]byte, 1<<precision)}, nil
+}
+
+// Add добавляет значение в скетч.
+func (h *HyperLogLog) Add(member string) {
+	hash := hashMember(member)
+	index := hash >> (64 - h.Precision)
+	// Сдвинутый хеш дополняется единицей, чтобы ранг не превышал 64-precision+1.
+	rank := byte(bits.LeadingZeros64(hash<<h.Precision|1<<(h.Precision-1)) + 1)
+	if rank > h.Registers[index] {
+		h.Registers[index] = rank
+	}
+}
+
+// Merge объединяет скетч с other. Точность скетчей должна совпадать.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h.Precision != other.Precision || len(h.Registers) != len(other.Registers) {
+		return errors2.ErrSketchMismatch
+	}
+	for i, rank := range other.Registers {
+		if rank > h.Registers[i] {
+			h.Registers[i] = rank
+		}
+	}
+	return nil
+}
+
+// Estimate возвращает оценку количества уникальных значений.
+func (h *HyperLogLog) Estimate() uint64 {
+	m := float64(len(h.Registers))
+	if m == 0 {
+		return 0
+	}
+
+	var sum float64
+	var zeros int
+	for _, rank := range h.Registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	// Для малых значений точнее линейный подсчет по пустым регистрам.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+// Clone возвращает копию скетча.
+func (h *HyperLogLog) Clone() *HyperLogLog {
+	return &HyperLogLog{Precision: h.Precision, Registers: append([]byte(nil), h.Registers...)}
+}
+
+// Validate проверяет скетч, полученный от клиента.
+func (h *HyperLogLog) Validate() error {
+	if h.Precision < minPrecision || h.Precision > maxPrecision || len(h.Registers) != 1<<h.Precision {
+		return fmt.Errorf("%w: invalid precision or registers", errors2.ErrInvalidMetricValue)
+	}
+	maxRank := byte(64 - h.Precision + 1)
+	for _, rank := range h.Registers {
+		if rank > maxRank {
+			return fmt.Errorf("%w: register rank is out of range", errors2.ErrInvalidMetricValue)
+		}
+	}
+	return nil
+}
+
+// hashMember возвращает 64-битный хеш значения.
+// FNV-1a дополняется финализатором splitmix64 для равномерного распределения старших бит.
+func hashMember(member string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(member))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
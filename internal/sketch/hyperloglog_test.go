@@ -0,0 +1,55 @@
+package sketch
+
+import (
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHyperLogLogEstimate(t *testing.T) {
+	tests := []struct {
+		name    string
+		members int
+	}{
+		{name: "small cardinality", members: 100},
+		{name: "large cardinality", members: 100000},
+	}
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			h, err := NewHyperLogLog(DefaultPrecision)
+			require.NoError(t, err)
+			for i := 0; i < v.members; i++ {
+				// Повторы не должны влиять на оценку.
+				h.Add("user-" + strconv.Itoa(i))
+				h.Add("user-" + strconv.Itoa(i))
+			}
+			assert.InEpsilon(t, v.members, h.Estimate(), 0.03)
+		})
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	first, _ := NewHyperLogLog(DefaultPrecision)
+	second, _ := NewHyperLogLog(DefaultPrecision)
+	for i := 0; i < 6000; i++ {
+		if i < 4000 {
+			first.Add("host-" + strconv.Itoa(i))
+		}
+		if i >= 2000 {
+			second.Add("host-" + strconv.Itoa(i))
+		}
+	}
+
+	merged := first.Clone()
+	require.NoError(t, merged.Merge(second))
+	assert.InEpsilon(t, 6000, merged.Estimate(), 0.03)
+	assert.InEpsilon(t, 4000, first.Estimate(), 0.03, "clone must not share registers")
+
+	other, _ := NewHyperLogLog(DefaultPrecision - 1)
+	assert.ErrorIs(t, merged.Merge(other), errors2.ErrSketchMismatch)
+	assert.NoError(t, merged.Validate())
+	assert.ErrorIs(t, (&HyperLogLog{Precision: DefaultPrecision}).Validate(), errors2.ErrInvalidMetricValue)
+}
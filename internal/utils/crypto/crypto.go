@@ -9,6 +9,7 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/binary"
 	"encoding/pem"
 	"fmt"
 	"hash"
@@ -104,6 +105,20 @@ func GetCertificate(filePath string) (*x509.Certificate, error) {
 	return cert, nil
 }
 
+// GetCertPool загружает пул корневых сертификатов из PEM файла.
+func GetCertPool(filePath string) (*x509.CertPool, error) {
+	caPEM, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA file: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("failed to parse CA certificates from %s", filePath)
+	}
+	return pool, nil
+}
+
 // DecryptRSAData разшифровывает данные c помлщью rsa
 func DecryptRSAData(privateKey *rsa.PrivateKey, ciphertext []byte) ([]byte, error) {
 	msgLen := len(ciphertext)
@@ -152,3 +167,81 @@ func EncryptRSAData(hash hash.Hash, cert *x509.Certificate, data []byte) ([]byte
 
 	return encryptedBytes, nil
 }
+
+// HybridVersion версия гибридного конверта: ключ AES-GCM, зашифрованный RSA-OAEP,
+// и данные, зашифрованные AES-GCM.
+const HybridVersion byte = 2
+
+// hybridKeySize размер ключа AES-256.
+const hybridKeySize = 32
+
+// EncryptHybrid зашифровывает данные случайным ключом AES-GCM, а ключ - публичным ключом RSA.
+//
+// Формат конверта: версия (1 байт), длина зашифрованного ключа (2 байта),
+// зашифрованный ключ, nonce, данные с тегом AES-GCM.
+func EncryptHybrid(publicKey *rsa.PublicKey, data []byte) ([]byte, error) {
+	key := make([]byte, hybridKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	envelope := make([]byte, 0, 3+len(encryptedKey)+len(nonce)+len(data)+aesgcm.Overhead())
+	envelope = append(envelope, HybridVersion)
+	envelope = binary.BigEndian.AppendUint16(envelope, uint16(len(encryptedKey)))
+	envelope = append(envelope, encryptedKey...)
+	envelope = append(envelope, nonce...)
+	return aesgcm.Seal(envelope, nonce, data, nil), nil
+}
+
+// DecryptHybrid расшифровывает конверт, созданный EncryptHybrid.
+func DecryptHybrid(privateKey *rsa.PrivateKey, envelope []byte) ([]byte, error) {
+	if len(envelope) < 3 || envelope[0] != HybridVersion {
+		return nil, fmt.Errorf("unsupported encryption envelope")
+	}
+
+	keyLen := int(binary.BigEndian.Uint16(envelope[1:3]))
+	envelope = envelope[3:]
+	if len(envelope) < keyLen {
+		return nil, fmt.Errorf("encryption envelope is too short")
+	}
+
+	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, envelope[:keyLen], nil)
+	if err != nil {
+		return nil, err
+	}
+	envelope = envelope[keyLen:]
+
+	aesgcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope) < aesgcm.NonceSize() {
+		return nil, fmt.Errorf("encryption envelope is too short")
+	}
+
+	nonce, ciphertext := envelope[:aesgcm.NonceSize()], envelope[aesgcm.NonceSize():]
+	return aesgcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	aesblock, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(aesblock)
+}
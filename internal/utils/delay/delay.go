@@ -5,17 +5,17 @@ import (
 	"time"
 )
 
-// NewDelay возвращает функцию с замыканием , которая увичивает задержку.
-func NewDelay() func() time.Duration {
+// NewDelay возвращает функцию с замыканием, которая увеличивает задержку:
+// первая задержка равна initial, каждая следующая получается из предыдущей функцией next.
+func NewDelay(initial time.Duration, next func(delay time.Duration) time.Duration) func() time.Duration {
 	attempt := 0
-	delay := 1 * time.Second
-	delayIncrease := 2 * time.Second
+	delay := initial
 	return func() time.Duration {
 		attempt++
 		if attempt == 1 {
 			return delay
 		}
-		delay += delayIncrease
+		delay = next(delay)
 		return delay
 	}
 }
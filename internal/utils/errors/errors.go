@@ -1,7 +1,11 @@
 // модуль errors содержит кастомные ошибки.
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 // Кастосные ошибки
 var (
@@ -9,4 +13,97 @@ var (
 	ErrInvalidCounterOperation = errors.New("invalid counter operation")
 	ErrInvalidCGaugeOperation  = errors.New("invalid gauge operation")
 	ErrInvalidMetricVType      = errors.New("invalid metric type")
+	ErrInvalidAggregation      = errors.New("invalid aggregation")
+	ErrInvalidQueryRange       = errors.New("invalid query range")
+	ErrMetricNotFound          = errors.New("metric not found")
+	ErrEmptyMetricID           = errors.New("empty metric id")
+	ErrMissingMetricValue      = errors.New("missing metric value")
+	ErrStorageUnavailable      = errors.New("storage unavailable")
+	ErrBucketsMismatch         = errors.New("histogram buckets mismatch")
+	ErrSketchMismatch          = errors.New("sketch accuracy mismatch")
+	ErrInvalidQuantile         = errors.New("invalid quantile")
 )
+
+// FieldError ошибка валидации поля запроса.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors возвращает все ошибки валидации полей, в том числе объединенные errors.Join.
+func FieldErrors(err error) []*FieldError {
+	var fieldErrors []*FieldError
+	var walk func(err error)
+	walk = func(err error) {
+		if err == nil {
+			return
+		}
+		switch e := err.(type) {
+		case *FieldError:
+			fieldErrors = append(fieldErrors, e)
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+	return fieldErrors
+}
+
+// IsValidation проверяет, что ошибка вызвана некорректными данными запроса.
+func IsValidation(err error) bool {
+	return len(FieldErrors(err)) > 0 ||
+		errors.Is(err, ErrInvalidMetricVType) ||
+		errors.Is(err, ErrInvalidMetricValue) ||
+		errors.Is(err, ErrEmptyMetricID) ||
+		errors.Is(err, ErrMissingMetricValue) ||
+		errors.Is(err, ErrBucketsMismatch) ||
+		errors.Is(err, ErrSketchMismatch) ||
+		errors.Is(err, ErrInvalidQuantile)
+}
+
+// ItemError ошибка валидации элемента батча с индексом Index.
+type ItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *ItemError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError ошибки валидации элементов батча.
+type BatchError struct {
+	Items []*ItemError
+}
+
+func (e *BatchError) Error() string {
+	messages := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		messages = append(messages, item.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Items))
+	for _, item := range e.Items {
+		errs = append(errs, item)
+	}
+	return errs
+}
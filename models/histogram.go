@@ -0,0 +1,84 @@
+package models
+
+import (
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"math"
+	"slices"
+)
+
+// Гистограмма хранит верхние границы корзин Buckets по возрастанию
+// и некумулятивные счетчики Counts. Последний счетчик относится к корзине +Inf,
+// поэтому len(Counts) == len(Buckets)+1.
+
+// mergeHistogram складывает гистограммы с одинаковыми границами корзин.
+func (m *Metrics) mergeHistogram(other Metrics) error {
+	if m.Sum == nil || m.Count == nil || other.Sum == nil || other.Count == nil {
+		return errors2.ErrMissingMetricValue
+	}
+	if !slices.Equal(m.Buckets, other.Buckets) || len(m.Counts) != len(other.Counts) {
+		return errors2.ErrBucketsMismatch
+	}
+
+	counts := make([]uint64, len(m.Counts))
+	for i := range counts {
+		counts[i] = m.Counts[i] + other.Counts[i]
+	}
+	sum := *m.Sum + *other.Sum
+	count := *m.Count + *other.Count
+
+	m.Counts = counts
+	m.Sum = &sum
+	m.Count = &count
+	return nil
+}
+
+// CumulativeCounts возвращает накопленные счетчики корзин, как в формате Prometheus.
+func (m *Metrics) CumulativeCounts() []uint64 {
+	cumulative := make([]uint64, len(m.Counts))
+	var total uint64
+	for i, count := range m.Counts {
+		total += count
+		cumulative[i] = total
+	}
+	return cumulative
+}
+
+// histogramQuantile оценивает квантиль q гистограммы линейной интерполяцией внутри корзины.
+// Для квантиля, попавшего в корзину +Inf, возвращается верхняя конечная граница.
+func (m *Metrics) histogramQuantile(q float64) (float64, error) {
+	if math.IsNaN(q) || q < 0 || q > 1 {
+		return 0, errors2.ErrInvalidQuantile
+	}
+
+	cumulative := m.CumulativeCounts()
+	if len(cumulative) == 0 || cumulative[len(cumulative)-1] == 0 {
+		return math.NaN(), nil
+	}
+
+	rank := q * float64(cumulative[len(cumulative)-1])
+	i, _ := slices.BinarySearchFunc(cumulative, rank, func(count uint64, rank float64) int {
+		if float64(count) < rank {
+			return -1
+		}
+		return 1
+	})
+	if i >= len(m.Buckets) {
+		if len(m.Buckets) == 0 {
+			return math.NaN(), nil
+		}
+		return m.Buckets[len(m.Buckets)-1], nil
+	}
+
+	lower, prev := 0.0, uint64(0)
+	if i > 0 {
+		lower, prev = m.Buckets[i-1], cumulative[i-1]
+	} else if m.Buckets[0] <= 0 {
+		return m.Buckets[0], nil
+	}
+	upper := m.Buckets[i]
+	inBucket := cumulative[i] - prev
+	if inBucket == 0 {
+		return upper, nil
+	}
+	return lower + (upper-lower)*((rank-float64(prev))/float64(inBucket)), nil
+}
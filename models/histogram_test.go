@@ -0,0 +1,60 @@
+package models
+
+import (
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHistogram(buckets []float64, counts []uint64, sum float64) Metrics {
+	var count uint64
+	for _, c := range counts {
+		count += c
+	}
+	return Metrics{ID: "Latency", MType: Histogram, Buckets: buckets, Counts: counts, Sum: &sum, Count: &count}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	stored := newHistogram([]float64{0.1, 0.5}, []uint64{1, 2, 0}, 1)
+	update := newHistogram([]float64{0.1, 0.5}, []uint64{2, 0, 1}, 2)
+	counts := stored.Counts
+
+	require.NoError(t, stored.Merge(update))
+	assert.Equal(t, []uint64{3, 2, 1}, stored.Counts)
+	assert.Equal(t, 3.0, *stored.Sum)
+	assert.Equal(t, uint64(6), *stored.Count)
+	assert.Equal(t, []uint64{1, 2, 0}, counts, "merge must not modify previous state")
+
+	mismatch := newHistogram([]float64{0.2, 0.5}, []uint64{0, 0, 1}, 1)
+	assert.ErrorIs(t, stored.Merge(mismatch), errors2.ErrBucketsMismatch)
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	h := newHistogram([]float64{1, 2, 4}, []uint64{2, 2, 0, 1}, 10)
+
+	tests := []struct {
+		q    float64
+		want float64
+	}{
+		{q: 0.2, want: 0.5},
+		{q: 0.6, want: 1.5},
+		{q: 0.8, want: 2},
+		{q: 1, want: 4},
+	}
+	for _, v := range tests {
+		got, err := h.Quantile(v.q)
+		require.NoError(t, err)
+		assert.InDelta(t, v.want, got, 1e-9, "q=%v", v.q)
+	}
+
+	_, err := h.Quantile(1.5)
+	assert.ErrorIs(t, err, errors2.ErrInvalidQuantile)
+
+	empty := newHistogram([]float64{1}, []uint64{0, 0}, 0)
+	got, err := empty.Quantile(0.5)
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(got))
+}
@@ -1,33 +1,74 @@
 package models
 
 import (
+	"encoding/json"
 	pb "go-svc-metrics/internal/pb/metric"
+	"go-svc-metrics/internal/sketch"
+	errors2 "go-svc-metrics/internal/utils/errors"
+	"slices"
+	"strings"
+	"time"
 )
 
 const (
-	Counter = "counter"
-	Gauge   = "gauge"
+	Counter   = "counter"
+	Gauge     = "gauge"
+	Histogram = "histogram"
+	Summary   = "summary"
+	Set       = "set"
 )
 
+// IsValidType проверяет, что тип метрики поддерживается.
+func IsValidType(mType string) bool {
+	switch mType {
+	case Counter, Gauge, Histogram, Summary, Set:
+		return true
+	}
+	return false
+}
+
 // NOTE: Не усложняем пример, вводя иерархическую вложенность структур.
 // Органичиваясь плоской моделью.
 // Delta и Value объявлены через указатели,
 // что бы отличать значение "0", от не заданного значения
 // и соответственно не кодировать в структуру.
+// Buckets, Counts, Sum и Count заполняются только для histogram, Sketch - для summary.
+// Для set клиент передает Members, а сервер хранит HLL.
 type Metrics struct {
-	ID    string   `json:"id"`
-	MType string   `json:"type"`
-	Delta *int64   `json:"delta,omitempty"`
-	Value *float64 `json:"value,omitempty"`
-	Hash  string   `json:"hash,omitempty"`
+	ID      string              `json:"id"`
+	MType   string              `json:"type"`
+	Delta   *int64              `json:"delta,omitempty"`
+	Value   *float64            `json:"value,omitempty"`
+	Buckets []float64           `json:"buckets,omitempty"`
+	Counts  []uint64            `json:"counts,omitempty"`
+	Sum     *float64            `json:"sum,omitempty"`
+	Count   *uint64             `json:"count,omitempty"`
+	Sketch  *sketch.DDSketch    `json:"sketch,omitempty"`
+	Members []string            `json:"members,omitempty"`
+	HLL     *sketch.HyperLogLog `json:"hll,omitempty"`
+	Labels  Labels              `json:"labels,omitempty"`
+	Hash    string              `json:"hash,omitempty"`
+}
+
+// Key возвращает идентификатор метрики: имя, тип и набор меток.
+func (m *Metrics) Key() string {
+	return strings.Join([]string{m.MType, m.ID, m.Labels.Encode()}, "\x00")
 }
 
 func (m *Metrics) ToProto() *pb.MetricMessage {
 	return &pb.MetricMessage{
-		Id:    m.ID,
-		Type:  m.MType,
-		Delta: m.Delta,
-		Value: m.Value,
+		Id:      m.ID,
+		Type:    m.MType,
+		Delta:   m.Delta,
+		Value:   m.Value,
+		Buckets: m.Buckets,
+		Counts:  m.Counts,
+		Sum:     m.Sum,
+		Count:   m.Count,
+		Sketch:  sketchToProto(m.Sketch),
+		Members: m.Members,
+		Hll:     hllToProto(m.HLL),
+		Labels:  m.Labels,
 	}
 }
 
@@ -36,13 +77,21 @@ func (m *Metrics) FromProto(in *pb.MetricMessage) Metrics {
 	m.MType = in.Type
 	m.Delta = in.Delta
 	m.Value = in.Value
+	m.Buckets = in.Buckets
+	m.Counts = in.Counts
+	m.Sum = in.Sum
+	m.Count = in.Count
+	m.Sketch = sketchFromProto(in.Sketch)
+	m.Members = in.Members
+	m.HLL = hllFromProto(in.Hll)
+	m.Labels = in.Labels
 	return *m
 }
 
 type BatchMetrics []Metrics
 
 func (b BatchMetrics) FromProto(in *pb.BatchMetricsMessage) BatchMetrics {
-	for _, message := range in.Metrics {
+	for _, message := range in.GetMetrics() {
 		var metric Metrics
 		b = append(b, metric.FromProto(message))
 	}
@@ -57,3 +106,151 @@ func (b BatchMetrics) ToProto() *pb.BatchMetricsMessage {
 	}
 	return &protoMessage
 }
+
+// Labels метки метрики, например хост или номер ядра.
+type Labels map[string]string
+
+// Encode возвращает каноничное представление меток.
+// Для пустого набора возвращается пустая строка.
+func (l Labels) Encode() string {
+	if len(l) == 0 {
+		return ""
+	}
+	// json.Marshal сортирует ключи map, поэтому представление однозначно.
+	data, err := json.Marshal(map[string]string(l))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// DecodeLabels восстанавливает метки из представления Encode.
+func DecodeLabels(data string) (Labels, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	var labels Labels
+	if err := json.Unmarshal([]byte(data), &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// Sample значение метрики в момент времени.
+// Для counter хранится накопленное значение после обновления.
+type Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// SampleValue возвращает текущее значение метрики для записи в историю.
+func (m *Metrics) SampleValue() (float64, bool) {
+	switch m.MType {
+	case Counter:
+		if m.Delta != nil {
+			return float64(*m.Delta), true
+		}
+	case Gauge:
+		if m.Value != nil {
+			return *m.Value, true
+		}
+	}
+	return 0, false
+}
+
+// Clone возвращает копию метрики, не разделяющую указатели и срезы с исходной.
+func (m Metrics) Clone() Metrics {
+	if m.Delta != nil {
+		delta := *m.Delta
+		m.Delta = &delta
+	}
+	if m.Value != nil {
+		value := *m.Value
+		m.Value = &value
+	}
+	if m.Sum != nil {
+		sum := *m.Sum
+		m.Sum = &sum
+	}
+	if m.Count != nil {
+		count := *m.Count
+		m.Count = &count
+	}
+	m.Buckets = slices.Clone(m.Buckets)
+	m.Counts = slices.Clone(m.Counts)
+	if m.Sketch != nil {
+		m.Sketch = m.Sketch.Clone()
+	}
+	m.Members = slices.Clone(m.Members)
+	if m.HLL != nil {
+		m.HLL = m.HLL.Clone()
+	}
+	return m
+}
+
+// Merge применяет обновление other к метрике:
+// counter складывается, gauge заменяется, у histogram складываются счетчики корзин, сумма и количество,
+// скетчи summary и set сливаются.
+func (m *Metrics) Merge(other Metrics) error {
+	switch m.MType {
+	case Counter:
+		if m.Delta == nil || other.Delta == nil {
+			return errors2.ErrMissingMetricValue
+		}
+		delta := *m.Delta + *other.Delta
+		m.Delta = &delta
+	case Gauge:
+		if other.Value == nil {
+			return errors2.ErrMissingMetricValue
+		}
+		value := *other.Value
+		m.Value = &value
+	case Histogram:
+		return m.mergeHistogram(other)
+	case Summary:
+		if m.Sketch == nil || other.Sketch == nil {
+			return errors2.ErrMissingMetricValue
+		}
+		merged := m.Sketch.Clone()
+		if err := merged.Merge(other.Sketch); err != nil {
+			return err
+		}
+		m.Sketch = merged
+	case Set:
+		if m.HLL == nil || other.HLL == nil {
+			return errors2.ErrMissingMetricValue
+		}
+		merged := m.HLL.Clone()
+		if err := merged.Merge(other.HLL); err != nil {
+			return err
+		}
+		m.HLL = merged
+	default:
+		return errors2.ErrInvalidMetricVType
+	}
+	return nil
+}
+
+// Quantile оценивает квантиль q метрики с распределением: histogram или summary.
+// Для метрики без наблюдений возвращается NaN.
+func (m *Metrics) Quantile(q float64) (float64, error) {
+	switch m.MType {
+	case Histogram:
+		return m.histogramQuantile(q)
+	case Summary:
+		if m.Sketch == nil {
+			return 0, errors2.ErrMissingMetricValue
+		}
+		return m.Sketch.Quantile(q)
+	}
+	return 0, errors2.ErrInvalidQuantile
+}
+
+// Cardinality возвращает оценку количества уникальных значений set.
+func (m *Metrics) Cardinality() uint64 {
+	if m.HLL == nil {
+		return 0
+	}
+	return m.HLL.Estimate()
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	pb "go-svc-metrics/internal/pb/metric"
+	"go-svc-metrics/internal/sketch"
+	"math"
+)
+
+func sketchToProto(s *sketch.DDSketch) *pb.Sketch {
+	if s == nil {
+		return nil
+	}
+	return &pb.Sketch{
+		Alpha:     s.RelativeAccuracy,
+		Positive:  s.Positive,
+		Negative:  s.Negative,
+		ZeroCount: s.ZeroCount,
+		Sum:       s.Sum,
+		Min:       s.Min,
+		Max:       s.Max,
+	}
+}
+
+func sketchFromProto(in *pb.Sketch) *sketch.DDSketch {
+	if in == nil {
+		return nil
+	}
+	return &sketch.DDSketch{
+		RelativeAccuracy: in.GetAlpha(),
+		Positive:         in.GetPositive(),
+		Negative:         in.GetNegative(),
+		ZeroCount:        in.GetZeroCount(),
+		Sum:              in.GetSum(),
+		Min:              in.GetMin(),
+		Max:              in.GetMax(),
+	}
+}
+
+func hllToProto(h *sketch.HyperLogLog) *pb.HyperLogLog {
+	if h == nil {
+		return nil
+	}
+	return &pb.HyperLogLog{Precision: uint32(h.Precision), Registers: h.Registers}
+}
+
+func hllFromProto(in *pb.HyperLogLog) *sketch.HyperLogLog {
+	if in == nil {
+		return nil
+	}
+	precision := in.GetPrecision()
+	if precision > math.MaxUint8 {
+		// Недопустимая точность, скетч не пройдет проверку.
+		precision = 0
+	}
+	return &sketch.HyperLogLog{Precision: uint8(precision), Registers: in.GetRegisters()}
+}